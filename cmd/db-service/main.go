@@ -9,12 +9,17 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Raisondetr3/checklist-db-service/internal/blob"
 	"github.com/Raisondetr3/checklist-db-service/internal/cache"
 	"github.com/Raisondetr3/checklist-db-service/internal/config"
+	"github.com/Raisondetr3/checklist-db-service/internal/notifier"
+	"github.com/Raisondetr3/checklist-db-service/internal/publisher"
 	"github.com/Raisondetr3/checklist-db-service/internal/repository"
 	"github.com/Raisondetr3/checklist-db-service/internal/service"
 	grpcTransport "github.com/Raisondetr3/checklist-db-service/internal/transport/grpc"
 	httpTransport "github.com/Raisondetr3/checklist-db-service/internal/transport/http"
+	"github.com/Raisondetr3/checklist-db-service/internal/watch"
+	"github.com/Raisondetr3/checklist-db-service/internal/worker"
 	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
 	"github.com/jackc/pgx/v5/pgxpool"
 
@@ -39,14 +44,21 @@ func main() {
 	}
 
 	logger.LogServiceStart("db-service", map[string]interface{}{
-		"http_port":      cfg.Server.HTTPPort,
-		"grpc_port":      cfg.Server.GRPCPort,
-		"db_host":        cfg.Database.Host,
-		"db_name":        cfg.Database.Name,
-		"log_level":      cfg.Logging.Level,
-		"redis_enabled":  cfg.Redis.Enabled,
-		"redis_shards":   len(cfg.Redis.URLs),
-		"redis_ttl":      cfg.Redis.TTL.String(),
+		"http_port":         cfg.Server.HTTPPort,
+		"grpc_port":         cfg.Server.GRPCPort,
+		"db_host":           cfg.Database.Host,
+		"db_name":           cfg.Database.Name,
+		"log_level":         cfg.Logging.Level,
+		"redis_enabled":     cfg.Redis.Enabled,
+		"redis_shards":      len(cfg.Redis.URLs),
+		"redis_ttl":         cfg.Redis.TTL.String(),
+		"purge_enabled":     cfg.Purge.Enabled,
+		"purge_retention":   cfg.Purge.Retention.String(),
+		"outbox_enabled":    cfg.Outbox.Enabled,
+		"outbox_publisher":  cfg.Outbox.Publisher,
+		"reminder_enabled":  cfg.Reminder.Enabled,
+		"reminder_notifier": cfg.Reminder.Notifier,
+		"attachment_store":  cfg.Attachment.Store,
 	})
 
 	defer logger.LogServiceStop("db-service", "shutdown")
@@ -92,8 +104,32 @@ func main() {
 			slog.Duration("ttl", cfg.Redis.TTL))
 	}
 
+	eventRepo := repository.NewTaskEventRepository(dbPool)
+	outboxRepo := repository.NewOutboxRepository(dbPool)
+	idempotencyRepo := repository.NewIdempotencyRepository(dbPool)
+	reminderRepo := repository.NewReminderRepository(dbPool)
+	listRepo := repository.NewTaskListRepository(dbPool)
+	fieldRepo := repository.NewCustomFieldRepository(dbPool)
+	templateRepo := repository.NewTemplateRepository(dbPool)
+	depRepo := repository.NewDependencyRepository(dbPool)
+	accessRepo := repository.NewAccessRepository(dbPool)
+	commentRepo := repository.NewCommentRepository(dbPool)
+	attachmentRepo := repository.NewAttachmentRepository(dbPool)
+	watchHub := watch.NewHub()
+
+	blobStore, err := blob.New(cfg.Attachment)
+	if err != nil {
+		slog.Error("Failed to initialize attachment store", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer func() {
+		if err := blobStore.Close(); err != nil {
+			slog.Error("Failed to close attachment store", slog.String("error", err.Error()))
+		}
+	}()
+
 	healthService := service.NewHealthService(healthRepo)
-	taskService := service.NewTaskService(taskRepo)
+	taskService := service.NewTaskService(taskRepo, listRepo, fieldRepo, templateRepo, depRepo, accessRepo, commentRepo, attachmentRepo, eventRepo, idempotencyRepo, blobStore, cfg.Attachment, watchHub)
 
 	handlers := httpTransport.NewHTTPHandlers(cfg, healthService)
 	httpServer := httpTransport.NewHTTPServer(cfg, handlers)
@@ -101,6 +137,69 @@ func main() {
 
 	var wg sync.WaitGroup
 
+	listener := watch.NewListener(dbPool, watchHub)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		listener.Start()
+	}()
+
+	var purger *worker.Purger
+	if cfg.Purge.Enabled {
+		purger = worker.NewPurger(cfg.Purge, taskRepo, eventRepo, outboxRepo, idempotencyRepo, attachmentRepo, blobStore)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			purger.Start()
+		}()
+	}
+
+	var relay *worker.Relay
+	if cfg.Outbox.Enabled {
+		pub, err := publisher.New(cfg.Outbox)
+		if err != nil {
+			slog.Error("Failed to initialize outbox publisher", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		defer func() {
+			if err := pub.Close(); err != nil {
+				slog.Error("Failed to close outbox publisher", slog.String("error", err.Error()))
+			}
+		}()
+
+		relay = worker.NewRelay(cfg.Outbox, outboxRepo, pub)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			relay.Start()
+		}()
+	}
+
+	var scheduler *worker.Scheduler
+	if cfg.Reminder.Enabled {
+		n, err := notifier.New(cfg.Reminder)
+		if err != nil {
+			slog.Error("Failed to initialize reminder notifier", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		defer func() {
+			if err := n.Close(); err != nil {
+				slog.Error("Failed to close reminder notifier", slog.String("error", err.Error()))
+			}
+		}()
+
+		scheduler = worker.NewScheduler(cfg.Reminder, reminderRepo, n)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scheduler.Start()
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
@@ -140,6 +239,32 @@ func main() {
 		slog.Error("Error stopping gRPC server", slog.String("error", err.Error()))
 	}
 
+	slog.Info("Stopping task event listener...")
+	if err := listener.Stop(ctx); err != nil {
+		slog.Error("Error stopping task event listener", slog.String("error", err.Error()))
+	}
+
+	if scheduler != nil {
+		slog.Info("Stopping reminder scheduler...")
+		if err := scheduler.Stop(ctx); err != nil {
+			slog.Error("Error stopping reminder scheduler", slog.String("error", err.Error()))
+		}
+	}
+
+	if relay != nil {
+		slog.Info("Stopping outbox relay...")
+		if err := relay.Stop(ctx); err != nil {
+			slog.Error("Error stopping outbox relay", slog.String("error", err.Error()))
+		}
+	}
+
+	if purger != nil {
+		slog.Info("Stopping purger...")
+		if err := purger.Stop(ctx); err != nil {
+			slog.Error("Error stopping purger", slog.String("error", err.Error()))
+		}
+	}
+
 	slog.Info("Waiting for servers to stop...")
 	wg.Wait()
 
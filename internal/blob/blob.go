@@ -0,0 +1,37 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/config"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore keeps the contents of attachments by storage key. Keys are
+// chosen by the caller and never reused, so a blob is written once and
+// never replaced.
+type BlobStore interface {
+	// Put stores exactly size bytes read from body under key.
+	Put(ctx context.Context, key string, body io.Reader, size int64) error
+	// Get returns ErrNotFound if nothing is stored under key. The caller
+	// closes the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete succeeds if nothing is stored under key.
+	Delete(ctx context.Context, key string) error
+	Close() error
+}
+
+func New(cfg config.AttachmentConfig) (BlobStore, error) {
+	switch cfg.Store {
+	case "local":
+		return NewLocalStore(cfg.LocalPath)
+	case "s3":
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown attachment store %q", cfg.Store)
+	}
+}
@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"hash/crc32"
 	"log/slog"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
 	"github.com/Raisondetr3/checklist-db-service/internal/model"
 	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
 	"github.com/go-redis/redis/v8"
@@ -20,9 +23,12 @@ type RedisCache interface {
 	SetTask(ctx context.Context, task *model.Task, ttl time.Duration) error
 	GetTask(ctx context.Context, id uuid.UUID) (*model.Task, error)
 	DeleteTask(ctx context.Context, id uuid.UUID) error
-	SetTaskList(ctx context.Context, tasks []*model.Task, ttl time.Duration) error
-	GetTaskList(ctx context.Context) ([]*model.Task, error)
-	InvalidateTaskList(ctx context.Context) error
+	SetTaskList(ctx context.Context, opts model.ListOptions, page *model.TaskPage, ttl time.Duration) error
+	GetTaskList(ctx context.Context, opts model.ListOptions) (*model.TaskPage, error)
+	InvalidateTaskList(ctx context.Context, listIDs ...uuid.UUID) error
+	InvalidateTasks(ctx context.Context, ids, listIDs []uuid.UUID) error
+	SetTaskTree(ctx context.Context, id uuid.UUID, limit int, tree *model.TaskTree, ttl time.Duration) error
+	GetTaskTree(ctx context.Context, id uuid.UUID, limit int) (*model.TaskTree, error)
 	
 	Ping(ctx context.Context) error
 	Close() error
@@ -99,7 +105,10 @@ func (r *redisCache) SetTask(ctx context.Context, task *model.Task, ttl time.Dur
 		return nil
 	}
 
-	key := r.taskKey(task.ID)
+	key, err := r.taskKey(ctx, task.ID)
+	if err != nil {
+		return err
+	}
 	shardIndex := r.getShardIndex(key)
 	client := r.getClient(key)
 	if client == nil {
@@ -128,7 +137,10 @@ func (r *redisCache) GetTask(ctx context.Context, id uuid.UUID) (*model.Task, er
 		return nil, errors.New("cache disabled")
 	}
 
-	key := r.taskKey(id)
+	key, err := r.taskKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 	shardIndex := r.getShardIndex(key)
 	client := r.getClient(key)
 	if client == nil {
@@ -168,7 +180,10 @@ func (r *redisCache) DeleteTask(ctx context.Context, id uuid.UUID) error {
 		return nil
 	}
 
-	key := r.taskKey(id)
+	key, err := r.taskKey(ctx, id)
+	if err != nil {
+		return err
+	}
 	shardIndex := r.getShardIndex(key)
 	client := r.getClient(key)
 	if client == nil {
@@ -178,19 +193,28 @@ func (r *redisCache) DeleteTask(ctx context.Context, id uuid.UUID) error {
 	start := time.Now()
 	logger.LogRedisShardSelection(ctx, key, shardIndex, "DELETE")
 
-	err := client.Del(ctx, key).Err()
+	err = client.Del(ctx, key).Err()
 	duration := time.Since(start)
 	
 	logger.LogCacheOperation(ctx, "DELETE", key, shardIndex, duration, err)
 	return err
 }
 
-func (r *redisCache) SetTaskList(ctx context.Context, tasks []*model.Task, ttl time.Duration) error {
+// Every page of a listing is stored as a field of a single hash, so
+// InvalidateTaskList drops all cached pages with one DEL. Listings scoped to
+// a task list get a hash of their own and are only dropped when a task of
+// that list changes. The hash expires ttl after its first page is written,
+// so caching more pages never keeps the older ones alive.
+func (r *redisCache) SetTaskList(ctx context.Context, opts model.ListOptions, page *model.TaskPage, ttl time.Duration) error {
 	if !r.enabled {
 		return nil
 	}
 
-	key := r.taskListKey()
+	key, err := r.taskListKey(ctx, opts.Filter.ListID)
+	if err != nil {
+		return err
+	}
+	field := opts.CacheKey()
 	shardIndex := r.getShardIndex(key)
 	client := r.getClient(key)
 	if client == nil {
@@ -200,25 +224,33 @@ func (r *redisCache) SetTaskList(ctx context.Context, tasks []*model.Task, ttl t
 	start := time.Now()
 	logger.LogRedisShardSelection(ctx, key, shardIndex, "SET_LIST")
 
-	data, err := json.Marshal(tasks)
+	data, err := json.Marshal(page)
 	if err != nil {
 		logger.LogCacheOperation(ctx, "SET_LIST", key, shardIndex, time.Since(start), err)
 		return err
 	}
 
-	err = client.Set(ctx, key, data, ttl).Err()
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, field, data)
+		pipe.ExpireNX(ctx, key, ttl)
+		return nil
+	})
 	duration := time.Since(start)
 	
 	logger.LogCacheOperation(ctx, "SET_LIST", key, shardIndex, duration, err)
 	return err
 }
 
-func (r *redisCache) GetTaskList(ctx context.Context) ([]*model.Task, error) {
+func (r *redisCache) GetTaskList(ctx context.Context, opts model.ListOptions) (*model.TaskPage, error) {
 	if !r.enabled {
 		return nil, errors.New("cache disabled")
 	}
 
-	key := r.taskListKey()
+	key, err := r.taskListKey(ctx, opts.Filter.ListID)
+	if err != nil {
+		return nil, err
+	}
+	field := opts.CacheKey()
 	shardIndex := r.getShardIndex(key)
 	client := r.getClient(key)
 	if client == nil {
@@ -228,7 +260,7 @@ func (r *redisCache) GetTaskList(ctx context.Context) ([]*model.Task, error) {
 	start := time.Now()
 	logger.LogRedisShardSelection(ctx, key, shardIndex, "GET_LIST")
 
-	data, err := client.Get(ctx, key).Result()
+	data, err := client.HGet(ctx, key, field).Result()
 	duration := time.Since(start)
 	
 	if err != nil {
@@ -242,23 +274,29 @@ func (r *redisCache) GetTaskList(ctx context.Context) ([]*model.Task, error) {
 
 	logger.LogRedisCacheHit(ctx, key, true, duration)
 
-	var tasks []*model.Task
-	err = json.Unmarshal([]byte(data), &tasks)
+	var page model.TaskPage
+	err = json.Unmarshal([]byte(data), &page)
 	if err != nil {
 		logger.LogCacheOperation(ctx, "GET_LIST", key, shardIndex, duration, err)
 		return nil, err
 	}
 
 	logger.LogCacheOperation(ctx, "GET_LIST", key, shardIndex, duration, nil)
-	return tasks, nil
+	return &page, nil
 }
 
-func (r *redisCache) InvalidateTaskList(ctx context.Context) error {
+// Trees are stored as fields of a hash per root task, one per limit, and
+// are dropped along with the root task.
+func (r *redisCache) SetTaskTree(ctx context.Context, id uuid.UUID, limit int, tree *model.TaskTree, ttl time.Duration) error {
 	if !r.enabled {
 		return nil
 	}
 
-	key := r.taskListKey()
+	key, err := r.taskTreeKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	field := strconv.Itoa(limit)
 	shardIndex := r.getShardIndex(key)
 	client := r.getClient(key)
 	if client == nil {
@@ -266,15 +304,127 @@ func (r *redisCache) InvalidateTaskList(ctx context.Context) error {
 	}
 
 	start := time.Now()
-	err := client.Del(ctx, key).Err()
+	logger.LogRedisShardSelection(ctx, key, shardIndex, "SET_TREE")
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		logger.LogCacheOperation(ctx, "SET_TREE", key, shardIndex, time.Since(start), err)
+		return err
+	}
+
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, field, data)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
 	duration := time.Since(start)
-	
-	logger.LogCacheInvalidation(ctx, key, "task_list_changed", err)
-	logger.LogCacheOperation(ctx, "DELETE_LIST", key, shardIndex, duration, err)
-	
+
+	logger.LogCacheOperation(ctx, "SET_TREE", key, shardIndex, duration, err)
 	return err
 }
 
+func (r *redisCache) GetTaskTree(ctx context.Context, id uuid.UUID, limit int) (*model.TaskTree, error) {
+	if !r.enabled {
+		return nil, errors.New("cache disabled")
+	}
+
+	key, err := r.taskTreeKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	field := strconv.Itoa(limit)
+	shardIndex := r.getShardIndex(key)
+	client := r.getClient(key)
+	if client == nil {
+		return nil, errors.New("no Redis client available")
+	}
+
+	start := time.Now()
+	logger.LogRedisShardSelection(ctx, key, shardIndex, "GET_TREE")
+
+	data, err := client.HGet(ctx, key, field).Result()
+	duration := time.Since(start)
+
+	if err != nil {
+		if err == redis.Nil {
+			logger.LogRedisCacheHit(ctx, key, false, duration)
+			return nil, errors.New("task tree not found in cache")
+		}
+		logger.LogCacheOperation(ctx, "GET_TREE", key, shardIndex, duration, err)
+		return nil, err
+	}
+
+	logger.LogRedisCacheHit(ctx, key, true, duration)
+
+	var tree model.TaskTree
+	err = json.Unmarshal([]byte(data), &tree)
+	if err != nil {
+		logger.LogCacheOperation(ctx, "GET_TREE", key, shardIndex, duration, err)
+		return nil, err
+	}
+
+	logger.LogCacheOperation(ctx, "GET_TREE", key, shardIndex, duration, nil)
+	return &tree, nil
+}
+
+// InvalidateTaskList drops the unscoped listing and the listings of the
+// given task lists.
+func (r *redisCache) InvalidateTaskList(ctx context.Context, listIDs ...uuid.UUID) error {
+	return r.InvalidateTasks(ctx, nil, listIDs)
+}
+
+// InvalidateTasks drops the given tasks and their trees, the unscoped
+// listing and the listings of the given task lists with one DEL per shard.
+func (r *redisCache) InvalidateTasks(ctx context.Context, ids, listIDs []uuid.UUID) error {
+	if !r.enabled {
+		return nil
+	}
+
+	listKeys, err := r.taskListKeys(ctx, listIDs)
+	if err != nil {
+		return err
+	}
+
+	keysByShard := make(map[int][]string)
+	for _, key := range listKeys {
+		shardIndex := r.getShardIndex(key)
+		keysByShard[shardIndex] = append(keysByShard[shardIndex], key)
+	}
+	for _, id := range ids {
+		key, err := r.taskKey(ctx, id)
+		if err != nil {
+			return err
+		}
+		treeKey, err := r.taskTreeKey(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, key := range []string{key, treeKey} {
+			shardIndex := r.getShardIndex(key)
+			keysByShard[shardIndex] = append(keysByShard[shardIndex], key)
+		}
+	}
+
+	var lastErr error
+	for shardIndex, keys := range keysByShard {
+		start := time.Now()
+		err := r.clients[shardIndex].Del(ctx, keys...).Err()
+		duration := time.Since(start)
+
+		logger.LogCacheOperation(ctx, "DELETE_BATCH", strings.Join(keys, ","), shardIndex, duration, err)
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	reason := "task_batch_changed"
+	if len(ids) == 0 {
+		reason = "task_list_changed"
+	}
+	logger.LogCacheInvalidation(ctx, strings.Join(listKeys, ","), reason, lastErr)
+	return lastErr
+}
+
 func (r *redisCache) Ping(ctx context.Context) error {
 	if !r.enabled {
 		return nil
@@ -315,12 +465,68 @@ func (r *redisCache) Close() error {
 	return lastErr
 }
 
-func (r *redisCache) taskKey(id uuid.UUID) string {
-	return fmt.Sprintf("task:%s", id.String())
+// Keys are namespaced by tenant and user so that callers never share a
+// cache entry.
+func (r *redisCache) namespace(ctx context.Context) (string, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tenant:%s:user:%s", url.QueryEscape(caller.TenantID), url.QueryEscape(caller.UserID)), nil
 }
 
-func (r *redisCache) taskListKey() string {
-	return "tasks:list"
+func (r *redisCache) taskKey(ctx context.Context, id uuid.UUID) (string, error) {
+	ns, err := r.namespace(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:task:%s", ns, id.String()), nil
+}
+
+func (r *redisCache) taskTreeKey(ctx context.Context, id uuid.UUID) (string, error) {
+	key, err := r.taskKey(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return key + ":tree", nil
+}
+
+// taskListKey returns the key of the unscoped listing, or of the listing
+// of one task list.
+func (r *redisCache) taskListKey(ctx context.Context, listID *uuid.UUID) (string, error) {
+	ns, err := r.namespace(ctx)
+	if err != nil {
+		return "", err
+	}
+	if listID != nil {
+		return fmt.Sprintf("%s:lists:%s:tasks", ns, listID.String()), nil
+	}
+	return ns + ":tasks:list", nil
+}
+
+// taskListKeys returns the key of the unscoped listing followed by those
+// of the given task lists, without duplicates.
+func (r *redisCache) taskListKeys(ctx context.Context, listIDs []uuid.UUID) ([]string, error) {
+	key, err := r.taskListKey(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{key}
+	seen := make(map[uuid.UUID]bool, len(listIDs))
+	for _, listID := range listIDs {
+		if seen[listID] {
+			continue
+		}
+		seen[listID] = true
+
+		key, err := r.taskListKey(ctx, &listID)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
 }
 
 func ParseRedisURLs(urls string) []string {
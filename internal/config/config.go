@@ -9,10 +9,14 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Logging  LoggingConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
+	Server     ServerConfig
+	Logging    LoggingConfig
+	Database   DatabaseConfig
+	Redis      RedisConfig
+	Purge      PurgeConfig
+	Outbox     OutboxConfig
+	Reminder   ReminderConfig
+	Attachment AttachmentConfig
 }
 
 type ServerConfig struct {
@@ -45,6 +49,65 @@ type RedisConfig struct {
 	TTL      time.Duration
 }
 
+type PurgeConfig struct {
+	Enabled         bool
+	Retention       time.Duration
+	EventRetention  time.Duration
+	OutboxRetention time.Duration
+	Interval        time.Duration
+	BatchSize       int
+}
+
+type OutboxConfig struct {
+	Enabled        bool
+	Publisher      string
+	WebhookURL     string
+	WebhookTimeout time.Duration
+	FilePath       string
+	Interval       time.Duration
+	BatchSize      int
+	Lease          time.Duration
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+type ReminderConfig struct {
+	Enabled        bool
+	Notifier       string
+	WebhookURL     string
+	WebhookTimeout time.Duration
+	Interval       time.Duration
+	BatchSize      int
+	Lease          time.Duration
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// AttachmentConfig limits attachments and selects where their contents are
+// kept. Quota caps the total size of the attachments on one owner's tasks.
+type AttachmentConfig struct {
+	Store     string
+	LocalPath string
+	MaxSize   int64
+	Quota     int64
+	ChunkSize int
+	S3        S3Config
+}
+
+// S3Config addresses an S3-compatible bucket. PathStyle puts the bucket in
+// the path rather than the host name, as most self-hosted stores expect.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	Timeout   time.Duration
+}
+
 func Load() (*Config, error) {
 	cfg := &Config{
 		Server: ServerConfig{
@@ -73,6 +136,55 @@ func Load() (*Config, error) {
 			DB:       getEnvInt("REDIS_DB", 0),
 			TTL:      time.Duration(getEnvInt("REDIS_TTL", 300)) * time.Second,
 		},
+		Purge: PurgeConfig{
+			Enabled:         getEnvBool("PURGE_ENABLED", true),
+			Retention:       getEnvDuration("PURGE_RETENTION", 30*24*time.Hour),
+			EventRetention:  getEnvDuration("PURGE_EVENT_RETENTION", 7*24*time.Hour),
+			OutboxRetention: getEnvDuration("PURGE_OUTBOX_RETENTION", 3*24*time.Hour),
+			Interval:        getEnvDuration("PURGE_INTERVAL", time.Hour),
+			BatchSize:       getEnvInt("PURGE_BATCH_SIZE", 1000),
+		},
+		Outbox: OutboxConfig{
+			Enabled:        getEnvBool("OUTBOX_ENABLED", true),
+			Publisher:      getEnv("OUTBOX_PUBLISHER", "log"),
+			WebhookURL:     getEnv("OUTBOX_WEBHOOK_URL", ""),
+			WebhookTimeout: getEnvDuration("OUTBOX_WEBHOOK_TIMEOUT", 10*time.Second),
+			FilePath:       getEnv("OUTBOX_FILE_PATH", "outbox/events.jsonl"),
+			Interval:       getEnvDuration("OUTBOX_INTERVAL", time.Second),
+			BatchSize:      getEnvInt("OUTBOX_BATCH_SIZE", 100),
+			Lease:          getEnvDuration("OUTBOX_LEASE", 5*time.Minute),
+			MaxAttempts:    getEnvInt("OUTBOX_MAX_ATTEMPTS", 10),
+			RetryBaseDelay: getEnvDuration("OUTBOX_RETRY_BASE_DELAY", time.Second),
+			RetryMaxDelay:  getEnvDuration("OUTBOX_RETRY_MAX_DELAY", 5*time.Minute),
+		},
+		Reminder: ReminderConfig{
+			Enabled:        getEnvBool("REMINDER_ENABLED", true),
+			Notifier:       getEnv("REMINDER_NOTIFIER", "log"),
+			WebhookURL:     getEnv("REMINDER_WEBHOOK_URL", ""),
+			WebhookTimeout: getEnvDuration("REMINDER_WEBHOOK_TIMEOUT", 10*time.Second),
+			Interval:       getEnvDuration("REMINDER_INTERVAL", 30*time.Second),
+			BatchSize:      getEnvInt("REMINDER_BATCH_SIZE", 100),
+			Lease:          getEnvDuration("REMINDER_LEASE", 5*time.Minute),
+			MaxAttempts:    getEnvInt("REMINDER_MAX_ATTEMPTS", 10),
+			RetryBaseDelay: getEnvDuration("REMINDER_RETRY_BASE_DELAY", time.Minute),
+			RetryMaxDelay:  getEnvDuration("REMINDER_RETRY_MAX_DELAY", time.Hour),
+		},
+		Attachment: AttachmentConfig{
+			Store:     getEnv("ATTACHMENT_STORE", "local"),
+			LocalPath: getEnv("ATTACHMENT_LOCAL_PATH", "attachments"),
+			MaxSize:   getEnvInt64("ATTACHMENT_MAX_SIZE", 25<<20),
+			Quota:     getEnvInt64("ATTACHMENT_QUOTA", 1<<30),
+			ChunkSize: getEnvInt("ATTACHMENT_CHUNK_SIZE", 64<<10),
+			S3: S3Config{
+				Endpoint:  getEnv("S3_ENDPOINT", ""),
+				Region:    getEnv("S3_REGION", "us-east-1"),
+				Bucket:    getEnv("S3_BUCKET", ""),
+				AccessKey: getEnv("S3_ACCESS_KEY", ""),
+				SecretKey: getEnv("S3_SECRET_KEY", ""),
+				PathStyle: getEnvBool("S3_PATH_STYLE", true),
+				Timeout:   getEnvDuration("S3_TIMEOUT", time.Minute),
+			},
+		},
 	}
 
 	return cfg, nil
@@ -119,6 +231,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -126,4 +247,13 @@ func getEnvBool(key string, defaultValue bool) bool {
 		}
 	}
 	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if durationValue, err := time.ParseDuration(value); err == nil {
+			return durationValue
+		}
+	}
+	return defaultValue
 }
\ No newline at end of file
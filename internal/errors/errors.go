@@ -1,9 +1,12 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"time"
 
+	"github.com/Raisondetr3/checklist-db-service/internal/repository"
+
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -34,9 +37,83 @@ func (e *ServiceError) ToGRPCStatus() error {
 var (
 	ErrTitleNotSpecified = NewServiceError(codes.InvalidArgument, "title is required")
 	ErrInvalidTaskId     = NewServiceError(codes.InvalidArgument, "invalid task id")
+	ErrInvalidPageSize   = NewServiceError(codes.InvalidArgument, "page size must not be negative")
+	ErrInvalidPageToken  = NewServiceError(codes.InvalidArgument, "invalid page token")
+	ErrInvalidSort       = NewServiceError(codes.InvalidArgument, "invalid sort field or direction")
+	ErrInvalidFilter     = NewServiceError(codes.InvalidArgument, "invalid filter: range start is after range end")
+	ErrQueryNotSpecified = NewServiceError(codes.InvalidArgument, "search query is required")
 	ErrTaskNotFound      = NewServiceError(codes.NotFound, "task not found")
+	ErrVersionMismatch   = NewServiceError(codes.Aborted, "task was modified concurrently: version mismatch")
+	ErrBatchEmpty        = NewServiceError(codes.InvalidArgument, "batch must contain at least one item")
+	ErrBatchTooLarge     = NewServiceError(codes.InvalidArgument, "batch exceeds the maximum size")
+	ErrBatchAborted      = NewServiceError(codes.Aborted, "batch aborted because another item failed")
+	ErrInvalidItemId     = NewServiceError(codes.InvalidArgument, "invalid checklist item id")
+	ErrItemTitleRequired = NewServiceError(codes.InvalidArgument, "checklist item title is required")
+	ErrInvalidItemOrder  = NewServiceError(codes.InvalidArgument, "item order must list every checklist item exactly once")
+	ErrItemNotFound      = NewServiceError(codes.NotFound, "checklist item not found")
+	ErrCompletedDerived  = NewServiceError(codes.FailedPrecondition, "completed is derived from checklist items")
 	ErrTaskAlreadyExists = NewServiceError(codes.AlreadyExists, "task already exists")
+	ErrInvalidClientId   = NewServiceError(codes.InvalidArgument, "task id must be a UUIDv4 or UUIDv7")
+	ErrInvalidRecurrence = NewServiceError(codes.InvalidArgument, "invalid or unsupported recurrence rule")
+	ErrOccurrenceExists  = NewServiceError(codes.AlreadyExists, "series already has an occurrence due then")
+	ErrInvalidWindow     = NewServiceError(codes.InvalidArgument, "start and end are required and start must be before end")
+	ErrInvalidPriority   = NewServiceError(codes.InvalidArgument, "priority must be between none and urgent")
+	ErrInvalidMove       = NewServiceError(codes.InvalidArgument, "exactly one of before_id or after_id must name another task")
 	ErrInternalError     = NewServiceError(codes.Internal, "internal server error")
+
+	ErrInvalidResumeToken = NewServiceError(codes.InvalidArgument, "invalid resume token")
+	ErrResumeTokenExpired = NewServiceError(codes.OutOfRange, "resume token has expired, resync with ListTasks")
+
+	ErrInvalidIdempotencyKey = NewServiceError(codes.InvalidArgument, "idempotency key must be at most 255 characters")
+	ErrIdempotencyKeyReused  = NewServiceError(codes.FailedPrecondition, "idempotency key was already used with a different request")
+
+	ErrInvalidTags         = NewServiceError(codes.InvalidArgument, "tags must be 1 to 64 characters and at most 50 per task")
+	ErrConstraintViolation = NewServiceError(codes.InvalidArgument, "request violates a data constraint")
+
+	ErrInvalidListId    = NewServiceError(codes.InvalidArgument, "invalid task list id")
+	ErrListNameRequired = NewServiceError(codes.InvalidArgument, "task list name is required")
+	ErrListNotFound     = NewServiceError(codes.NotFound, "task list not found")
+	ErrListNotEmpty     = NewServiceError(codes.FailedPrecondition, "task list still holds tasks")
+
+	ErrInvalidCustomField       = NewServiceError(codes.InvalidArgument, "custom field key must be 1 to 64 lowercase letters, digits or underscores and only enum fields take options")
+	ErrInvalidCustomFieldValue  = NewServiceError(codes.InvalidArgument, "custom field values must suit the fields defined for the task's list")
+	ErrInvalidCustomFieldFilter = NewServiceError(codes.InvalidArgument, "custom field filters require list_id and must suit the fields defined for the list")
+	ErrCustomFieldExists        = NewServiceError(codes.AlreadyExists, "task list already defines the custom field")
+	ErrTooManyCustomFields      = NewServiceError(codes.FailedPrecondition, "task list has too many custom fields")
+	ErrCustomFieldNotFound      = NewServiceError(codes.NotFound, "custom field not found")
+
+	ErrInvalidTemplateId     = NewServiceError(codes.InvalidArgument, "invalid task template id")
+	ErrInvalidTemplate       = NewServiceError(codes.InvalidArgument, "template items and subtasks need titles, with at most 100 items and 50 subtasks")
+	ErrInvalidTemplateParams = NewServiceError(codes.InvalidArgument, "every template placeholder needs a parameter and no title may end up empty")
+	ErrTemplateNotFound      = NewServiceError(codes.NotFound, "task template not found")
+
+	ErrInvalidDependency  = NewServiceError(codes.InvalidArgument, "task_id and blocker_id must be two different task ids")
+	ErrDependencyCycle    = NewServiceError(codes.FailedPrecondition, "dependency would create a cycle")
+	ErrDependencyNotFound = NewServiceError(codes.NotFound, "dependency not found")
+	ErrTaskBlocked        = NewServiceError(codes.FailedPrecondition, "task cannot be completed while it has open blockers")
+
+	ErrInvalidParentId = NewServiceError(codes.InvalidArgument, "invalid parent task id")
+	ErrParentNotFound  = NewServiceError(codes.NotFound, "parent task not found")
+	ErrInvalidParent   = NewServiceError(codes.InvalidArgument, "task cannot be nested under itself or its subtasks")
+	ErrTaskHasChildren = NewServiceError(codes.FailedPrecondition, "task has subtasks, delete them first or cascade")
+
+	ErrPermissionDenied = NewServiceError(codes.PermissionDenied, "caller is not allowed to do this with the task")
+	ErrInvalidUserId    = NewServiceError(codes.InvalidArgument, "user id is required and must not be the task owner")
+	ErrInvalidGrant     = NewServiceError(codes.InvalidArgument, "permission must be read or edit")
+	ErrGrantNotFound    = NewServiceError(codes.NotFound, "task is not shared with the user")
+	ErrAssigneeNotFound = NewServiceError(codes.NotFound, "user is not assigned to the task")
+
+	ErrInvalidCommentId = NewServiceError(codes.InvalidArgument, "invalid comment id")
+	ErrInvalidComment   = NewServiceError(codes.InvalidArgument, "comment body must be 1 to 10000 characters")
+	ErrCommentNotFound  = NewServiceError(codes.NotFound, "comment not found")
+
+	ErrInvalidAttachmentId   = NewServiceError(codes.InvalidArgument, "invalid attachment id")
+	ErrInvalidAttachmentName = NewServiceError(codes.InvalidArgument, "attachment name must be 1 to 255 characters")
+	ErrInvalidUpload         = NewServiceError(codes.InvalidArgument, "upload must start with the attachment metadata followed by chunks")
+	ErrAttachmentTooLarge    = NewServiceError(codes.InvalidArgument, "attachment exceeds the size limit")
+	ErrQuotaExceeded         = NewServiceError(codes.ResourceExhausted, "attachment quota exceeded")
+	ErrAttachmentNotFound    = NewServiceError(codes.NotFound, "attachment not found")
+	ErrBlobStoreUnavailable  = NewServiceError(codes.Unavailable, "attachment storage is unavailable")
 )
 
 func WrapRepositoryError(err error) *ServiceError {
@@ -47,17 +124,57 @@ func WrapRepositoryError(err error) *ServiceError {
 	switch {
 	case IsNotFoundError(err):
 		return ErrTaskNotFound
+	case repository.IsListNotFoundError(err):
+		return ErrListNotFound
+	case repository.IsListNotEmptyError(err):
+		return ErrListNotEmpty
+	case stderrors.Is(err, repository.ErrCustomFieldExists):
+		return ErrCustomFieldExists
+	case stderrors.Is(err, repository.ErrOccurrenceExists):
+		return ErrOccurrenceExists
+	case stderrors.Is(err, repository.ErrTooManyCustomFields):
+		return ErrTooManyCustomFields
+	case stderrors.Is(err, repository.ErrCustomFieldNotFound):
+		return ErrCustomFieldNotFound
+	case stderrors.Is(err, repository.ErrTemplateNotFound):
+		return ErrTemplateNotFound
+	case stderrors.Is(err, repository.ErrDependencyCycle):
+		return ErrDependencyCycle
+	case stderrors.Is(err, repository.ErrDependencyNotFound):
+		return ErrDependencyNotFound
+	case stderrors.Is(err, repository.ErrTaskBlocked):
+		return ErrTaskBlocked
+	case stderrors.Is(err, repository.ErrCompletedDerived):
+		return ErrCompletedDerived
+	case stderrors.Is(err, repository.ErrParentNotFound):
+		return ErrParentNotFound
+	case stderrors.Is(err, repository.ErrInvalidParent):
+		return ErrInvalidParent
+	case stderrors.Is(err, repository.ErrTaskHasChildren):
+		return ErrTaskHasChildren
+	case stderrors.Is(err, repository.ErrGrantNotFound):
+		return ErrGrantNotFound
+	case stderrors.Is(err, repository.ErrAssigneeNotFound):
+		return ErrAssigneeNotFound
+	case stderrors.Is(err, repository.ErrCommentNotFound):
+		return ErrCommentNotFound
+	case stderrors.Is(err, repository.ErrAttachmentNotFound):
+		return ErrAttachmentNotFound
+	case stderrors.Is(err, repository.ErrQuotaExceeded):
+		return ErrQuotaExceeded
 	case IsConstraintViolationError(err):
 		return ErrTaskAlreadyExists
+	case stderrors.Is(err, repository.ErrConstraintViolation):
+		return ErrConstraintViolation
 	default:
 		return NewServiceError(codes.Internal, fmt.Sprintf("repository error: %v", err))
 	}
 }
 
 func IsNotFoundError(err error) bool {
-	return err.Error() == "no rows in result set"
+	return repository.IsNotFoundError(err)
 }
 
 func IsConstraintViolationError(err error) bool {
-	return false 
+	return stderrors.Is(err, repository.ErrTaskAlreadyExists)
 }
\ No newline at end of file
@@ -0,0 +1,56 @@
+package identity
+
+import (
+	"context"
+	"errors"
+)
+
+const DefaultTenantID = "default"
+
+var ErrMissingIdentity = errors.New("caller identity is missing")
+
+type Identity struct {
+	TenantID string
+	UserID   string
+}
+
+type contextKey struct{}
+
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+func FromContext(ctx context.Context) (Identity, error) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+	if !ok || id.UserID == "" || id.TenantID == "" {
+		return Identity{}, ErrMissingIdentity
+	}
+	return id, nil
+}
+
+type actorKey struct{}
+
+// OnBehalfOf scopes ctx to the data of another user of the caller's tenant,
+// typically the owner of a task shared with the caller. The caller stays
+// the actor recorded in task history.
+func OnBehalfOf(ctx context.Context, userID string) context.Context {
+	caller, err := FromContext(ctx)
+	if err != nil || caller.UserID == userID {
+		return ctx
+	}
+
+	ctx = context.WithValue(ctx, actorKey{}, Actor(ctx))
+	return WithIdentity(ctx, Identity{TenantID: caller.TenantID, UserID: userID})
+}
+
+// Actor returns the user acting in ctx: the caller that OnBehalfOf
+// delegated from, or else the identity's user.
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok {
+		return actor
+	}
+	if caller, err := FromContext(ctx); err == nil {
+		return caller.UserID
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidGrant  = errors.New("invalid grant")
+	ErrInvalidUserID = errors.New("invalid user id")
+)
+
+// Permission is what a user may do with a task. Higher permissions include
+// the lower ones.
+type Permission int
+
+const (
+	PermissionNone Permission = iota
+	PermissionRead
+	PermissionEdit
+	PermissionOwner
+)
+
+// Allows reports whether p includes required.
+func (p Permission) Allows(required Permission) bool {
+	return p >= required
+}
+
+// String returns the value stored in task_acl.permission.
+func (p Permission) String() string {
+	switch p {
+	case PermissionRead:
+		return "read"
+	case PermissionEdit:
+		return "edit"
+	case PermissionOwner:
+		return "owner"
+	default:
+		return "none"
+	}
+}
+
+func ParsePermission(s string) Permission {
+	switch s {
+	case "read":
+		return PermissionRead
+	case "edit":
+		return PermissionEdit
+	case "owner":
+		return PermissionOwner
+	default:
+		return PermissionNone
+	}
+}
+
+// Access is the caller's permission on one task together with the task's
+// owner, in whose scope the task is read and written.
+type Access struct {
+	OwnerID    string
+	Permission Permission
+}
+
+// TaskGrant shares a task with a user other than its owner.
+type TaskGrant struct {
+	TaskID     uuid.UUID
+	UserID     string
+	Permission Permission
+	CreatedAt  time.Time
+}
+
+func NewTaskGrant(taskID uuid.UUID, userID string, permission Permission) (TaskGrant, error) {
+	if userID == "" {
+		return TaskGrant{}, ErrInvalidUserID
+	}
+	if permission != PermissionRead && permission != PermissionEdit {
+		return TaskGrant{}, ErrInvalidGrant
+	}
+	return TaskGrant{TaskID: taskID, UserID: userID, Permission: permission, CreatedAt: time.Now()}, nil
+}
+
+// TaskAccess lists who besides the owner can reach a task. Assignees may
+// edit the task whether or not they hold a grant.
+type TaskAccess struct {
+	TaskID      uuid.UUID
+	OwnerID     string
+	Grants      []TaskGrant
+	AssigneeIDs []string
+}
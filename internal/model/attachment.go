@@ -0,0 +1,63 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxAttachmentNameLength = 255
+	DefaultContentType      = "application/octet-stream"
+)
+
+var (
+	ErrInvalidAttachmentName = errors.New("attachment name must be 1 to 255 characters")
+	ErrInvalidAttachmentID   = errors.New("invalid attachment id")
+)
+
+// Attachment describes a file attached to a task. Its contents are kept in
+// the blob store under StorageKey; Checksum is their hex-encoded SHA-256.
+type Attachment struct {
+	ID          uuid.UUID
+	TaskID      uuid.UUID
+	UploaderID  string
+	Name        string
+	Size        int64
+	ContentType string
+	Checksum    string
+	StorageKey  string
+	CreatedAt   time.Time
+}
+
+// NewAttachment returns the attachment before its contents are uploaded.
+func NewAttachment(taskID uuid.UUID, name, contentType string) (*Attachment, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxAttachmentNameLength {
+		return nil, ErrInvalidAttachmentName
+	}
+	contentType = strings.TrimSpace(contentType)
+	if contentType == "" {
+		contentType = DefaultContentType
+	}
+
+	id := uuid.New()
+	return &Attachment{
+		ID:          id,
+		TaskID:      taskID,
+		Name:        name,
+		ContentType: contentType,
+		StorageKey:  taskID.String() + "/" + id.String(),
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// BlobDeletion is the contents of a removed attachment still waiting to be
+// deleted from the blob store.
+type BlobDeletion struct {
+	ID         int64
+	StorageKey string
+}
@@ -0,0 +1,25 @@
+package model
+
+import "github.com/google/uuid"
+
+const MaxBatchSize = 1000
+
+type TaskPatch struct {
+	ID              uuid.UUID
+	Update          TaskUpdate
+	ExpectedVersion *int64
+}
+
+type TaskDeletion struct {
+	ID              uuid.UUID
+	ExpectedVersion *int64
+	// Cascade deletes the task's subtasks along with it; without it a task
+	// with subtasks is not deleted.
+	Cascade bool
+}
+
+// BatchResult is the outcome of one item of a batch, in request order.
+type BatchResult struct {
+	Task *Task
+	Err  error
+}
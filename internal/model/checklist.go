@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChecklistItem struct {
+	ID        uuid.UUID
+	TaskID    uuid.UUID
+	Title     string
+	Completed bool
+	Position  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewChecklistItem(taskID uuid.UUID, title string) *ChecklistItem {
+	return &ChecklistItem{
+		ID:        uuid.New(),
+		TaskID:    taskID,
+		Title:     title,
+		Completed: false,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// ItemsCompleted reports whether a task with these items counts as done:
+// it must have at least one item and all of them must be completed.
+func ItemsCompleted(items []*ChecklistItem) bool {
+	if len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if !item.Completed {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// MaxCommentLength bounds the characters in a comment body.
+const MaxCommentLength = 10000
+
+var (
+	ErrInvalidComment   = errors.New("comment body must be 1 to 10000 characters")
+	ErrInvalidCommentID = errors.New("invalid comment id")
+)
+
+// Comment is a message left on a task. EditedAt is set once the body has
+// been changed.
+type Comment struct {
+	ID        uuid.UUID
+	TaskID    uuid.UUID
+	AuthorID  string
+	Body      string
+	CreatedAt time.Time
+	EditedAt  *time.Time
+}
+
+func NewComment(taskID uuid.UUID, authorID, body string) *Comment {
+	return &Comment{
+		ID:        uuid.New(),
+		TaskID:    taskID,
+		AuthorID:  authorID,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+}
+
+// CommentBody trims body and checks its length.
+func CommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > MaxCommentLength {
+		return "", ErrInvalidComment
+	}
+	return body, nil
+}
+
+// CommentCursor returns the keyset position after c in the comments of a
+// task, which are ordered by creation.
+func CommentCursor(c *Comment) *PageCursor {
+	return &PageCursor{
+		Sort:  TaskSort{Field: SortByCreatedAt},
+		Value: c.CreatedAt.Format(time.RFC3339Nano),
+		ID:    c.ID,
+	}
+}
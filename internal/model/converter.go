@@ -1,6 +1,9 @@
 package model
 
 import (
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -13,11 +16,28 @@ func TaskToProto(task *Task) *pb.Task {
 	
 	return &pb.Task{
 		Id:          task.ID.String(),
+		TenantId:    task.TenantID,
+		OwnerId:     task.OwnerID,
 		Title:       task.Title,
 		Description: task.Description,
 		Completed:   task.Completed,
 		CreatedAt:   timestamppb.New(task.CreatedAt),
 		UpdatedAt:   timestamppb.New(task.UpdatedAt),
+
+		CompletedFromItems: task.CompletedFromItems,
+		Items:              ChecklistItemsToProto(task.Items),
+		Version:            task.Version,
+		DeletedAt:          timestampToProto(task.DeletedAt),
+		DueAt:              timestampToProto(task.DueAt),
+		RemindAt:           timestampToProto(task.RemindAt),
+		Recurrence:         task.Recurrence,
+		SeriesId:           uuidToProto(task.SeriesID),
+		Tags:               task.Tags,
+		Priority:           pb.TaskPriority(task.Priority),
+		Position:           task.Position,
+		ListId:             uuidToProto(task.ListID),
+		ParentId:           uuidToProto(task.ParentID),
+		CustomFields:       CustomFieldValuesToProto(task.CustomFields),
 	}
 }
 
@@ -31,13 +51,46 @@ func TaskFromProto(protoTask *pb.Task) (*Task, error) {
 		return nil, err
 	}
 
+	seriesID, err := uuidFromProto(protoTask.SeriesId)
+	if err != nil {
+		return nil, err
+	}
+	listID, err := uuidFromProto(protoTask.ListId)
+	if err != nil {
+		return nil, err
+	}
+	parentID, err := uuidFromProto(protoTask.ParentId)
+	if err != nil {
+		return nil, err
+	}
+	customFields, err := CustomFieldValuesFromProto(protoTask.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Task{
 		ID:          id,
+		TenantID:    protoTask.TenantId,
+		OwnerID:     protoTask.OwnerId,
 		Title:       protoTask.Title,
 		Description: protoTask.Description,
 		Completed:   protoTask.Completed,
 		CreatedAt:   protoTask.CreatedAt.AsTime(),
 		UpdatedAt:   protoTask.UpdatedAt.AsTime(),
+
+		CompletedFromItems: protoTask.CompletedFromItems,
+		Version:            protoTask.Version,
+		DeletedAt:          timeFromProto(protoTask.DeletedAt),
+		DueAt:              timeFromProto(protoTask.DueAt),
+		RemindAt:           timeFromProto(protoTask.RemindAt),
+		Recurrence:         protoTask.Recurrence,
+		SeriesID:           seriesID,
+		Tags:               protoTask.Tags,
+		Priority:           Priority(protoTask.Priority),
+		Position:           protoTask.Position,
+		ListID:             listID,
+		ParentID:           parentID,
+		CustomFields:       customFields,
 	}, nil
 }
 
@@ -53,6 +106,94 @@ func TasksToProto(tasks []*Task) []*pb.Task {
 	return protoTasks
 }
 
+func ChecklistItemsToProto(items []*ChecklistItem) []*pb.ChecklistItem {
+	if items == nil {
+		return nil
+	}
+
+	protoItems := make([]*pb.ChecklistItem, len(items))
+	for i, item := range items {
+		protoItems[i] = &pb.ChecklistItem{
+			Id:        item.ID.String(),
+			TaskId:    item.TaskID.String(),
+			Title:     item.Title,
+			Completed: item.Completed,
+			Position:  int32(item.Position),
+			CreatedAt: timestamppb.New(item.CreatedAt),
+			UpdatedAt: timestamppb.New(item.UpdatedAt),
+		}
+	}
+	return protoItems
+}
+
+func SearchResultsToProto(results []*SearchResult) []*pb.SearchResult {
+	if results == nil {
+		return nil
+	}
+
+	protoResults := make([]*pb.SearchResult, len(results))
+	for i, result := range results {
+		protoResults[i] = &pb.SearchResult{
+			Task:                 TaskToProto(result.Task),
+			Rank:                 result.Rank,
+			TitleHighlight:       result.TitleHighlight,
+			DescriptionHighlight: result.DescriptionHighlight,
+		}
+	}
+	return protoResults
+}
+
+func TaskEventToProto(event *TaskEvent) *pb.TaskEvent {
+	if event == nil {
+		return nil
+	}
+
+	return &pb.TaskEvent{
+		Type:        taskEventTypeToProto(event.Type),
+		Task:        TaskToProto(event.Task),
+		ResumeToken: event.Position.Encode(),
+		OccurredAt:  timestamppb.New(event.OccurredAt),
+	}
+}
+
+func taskEventTypeToProto(eventType TaskEventType) pb.TaskEventType {
+	switch eventType {
+	case TaskCreated:
+		return pb.TaskEventType_TASK_EVENT_TYPE_CREATED
+	case TaskUpdated:
+		return pb.TaskEventType_TASK_EVENT_TYPE_UPDATED
+	case TaskDeleted:
+		return pb.TaskEventType_TASK_EVENT_TYPE_DELETED
+	case TaskRestored:
+		return pb.TaskEventType_TASK_EVENT_TYPE_RESTORED
+	case TaskPurged:
+		return pb.TaskEventType_TASK_EVENT_TYPE_PURGED
+	default:
+		return pb.TaskEventType_TASK_EVENT_TYPE_UNSPECIFIED
+	}
+}
+
+func TaskHistoryToProto(entries []*TaskHistoryEntry) []*pb.TaskHistoryEntry {
+	if entries == nil {
+		return nil
+	}
+
+	protoEntries := make([]*pb.TaskHistoryEntry, len(entries))
+	for i, entry := range entries {
+		protoEntries[i] = &pb.TaskHistoryEntry{
+			Id:        entry.ID,
+			TaskId:    entry.TaskID.String(),
+			Action:    taskEventTypeToProto(entry.Action),
+			ActorId:   entry.ActorID,
+			RequestId: entry.RequestID,
+			Before:    TaskToProto(entry.Before),
+			After:     TaskToProto(entry.After),
+			CreatedAt: timestamppb.New(entry.CreatedAt),
+		}
+	}
+	return protoEntries
+}
+
 func CreateTaskRequestFromProto(req *pb.CreateTaskRequest) (title, description string) {
 	if req == nil {
 		return "", ""
@@ -60,22 +201,102 @@ func CreateTaskRequestFromProto(req *pb.CreateTaskRequest) (title, description s
 	return req.Title, req.Description
 }
 
-func UpdateTaskRequestFromProto(req *pb.UpdateTaskRequest) (title, description *string, completed *bool) {
+func CreateTaskScheduleFromProto(req *pb.CreateTaskRequest) (dueAt, remindAt *time.Time) {
+	if req == nil {
+		return nil, nil
+	}
+	return timeFromProto(req.DueAt), timeFromProto(req.RemindAt)
+}
+
+// CreateTaskIDFromProto returns the client-supplied id, or uuid.Nil when the
+// server should generate one.
+func CreateTaskIDFromProto(req *pb.CreateTaskRequest) (uuid.UUID, error) {
+	if req == nil || req.Id == "" {
+		return uuid.Nil, nil
+	}
+	return ParseClientID(req.Id)
+}
+
+func UpdateTaskRequestFromProto(req *pb.UpdateTaskRequest) TaskUpdate {
 	if req == nil {
-		return nil, nil, nil
+		return TaskUpdate{}
+	}
+
+	return TaskUpdate{
+		Title:              req.Title,
+		Description:        req.Description,
+		Completed:          req.Completed,
+		CompletedFromItems: req.CompletedFromItems,
+		DueAt:              timeFromProto(req.DueAt),
+		RemindAt:           timeFromProto(req.RemindAt),
+		ClearDueAt:         req.ClearDueAt,
+		ClearRemindAt:      req.ClearRemindAt,
+		Recurrence:         req.Recurrence,
+		AddTags:            req.AddTags,
+		RemoveTags:         req.RemoveTags,
+		Priority:           priorityFromProto(req.Priority),
+		ClearListID:        req.ClearListId,
+		ClearParentID:      req.ClearParentId,
+		ClearCustomFields:  req.ClearCustomFields,
+	}
+}
+
+// ListIDFromProto parses the list a task is filed in; an empty id means
+// none.
+func ListIDFromProto(id string) (*uuid.UUID, error) {
+	listID, err := uuidFromProto(id)
+	if err != nil {
+		return nil, ErrInvalidListID
+	}
+	return listID, nil
+}
+
+// ParentIDFromProto parses the task a task is nested under; an empty id
+// means none.
+func ParentIDFromProto(id string) (*uuid.UUID, error) {
+	parentID, err := uuidFromProto(id)
+	if err != nil {
+		return nil, ErrInvalidParentID
+	}
+	return parentID, nil
+}
+
+func priorityFromProto(priority *pb.TaskPriority) *Priority {
+	if priority == nil {
+		return nil
+	}
+	p := Priority(*priority)
+	return &p
+}
+
+// MoveTaskRequestFromProto returns the task to move, the task to place it
+// next to and whether it goes before that task.
+func MoveTaskRequestFromProto(req *pb.MoveTaskRequest) (id, anchorID uuid.UUID, before bool, err error) {
+	id, err = uuid.Parse(req.Id)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false, err
 	}
 
-	if req.Title != nil {
-		title = req.Title
+	switch target := req.Target.(type) {
+	case *pb.MoveTaskRequest_BeforeId:
+		anchorID, err = uuid.Parse(target.BeforeId)
+		before = true
+	case *pb.MoveTaskRequest_AfterId:
+		anchorID, err = uuid.Parse(target.AfterId)
+	default:
+		err = ErrInvalidMove
 	}
-	if req.Description != nil {
-		description = req.Description
+	if err == nil && anchorID == id {
+		err = ErrInvalidMove
 	}
-	if req.Completed != nil {
-		completed = req.Completed
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false, ErrInvalidMove
 	}
+	return id, anchorID, before, nil
+}
 
-	return title, description, completed
+func BatchAtomicFromProto(mode pb.BatchMode) bool {
+	return mode != pb.BatchMode_BATCH_MODE_BEST_EFFORT
 }
 
 func GetTaskRequestFromProto(req *pb.GetTaskRequest) (uuid.UUID, error) {
@@ -92,4 +313,770 @@ func DeleteTaskRequestFromProto(req *pb.DeleteTaskRequest) (uuid.UUID, error) {
 	return uuid.Parse(req.Id)
 }
 
+func RestoreTaskRequestFromProto(req *pb.RestoreTaskRequest) (uuid.UUID, error) {
+	if req == nil {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(req.Id)
+}
+
+func PurgeTaskRequestFromProto(req *pb.PurgeTaskRequest) (uuid.UUID, error) {
+	if req == nil {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(req.Id)
+}
+
+func GetTaskHistoryRequestFromProto(req *pb.GetTaskHistoryRequest) (uuid.UUID, HistoryOptions, error) {
+	if req == nil {
+		return uuid.Nil, HistoryOptions{}, nil
+	}
+
+	id, err := uuid.Parse(req.TaskId)
+	if err != nil {
+		return uuid.Nil, HistoryOptions{}, err
+	}
+
+	cursor, err := DecodeHistoryCursor(req.PageToken)
+	if err != nil {
+		return uuid.Nil, HistoryOptions{}, err
+	}
+
+	return id, HistoryOptions{
+		PageSize: int(req.PageSize),
+		Cursor:   cursor,
+	}, nil
+}
+
+func ListTasksRequestFromProto(req *pb.ListTasksRequest) (ListOptions, error) {
+	opts := ListOptions{Sort: DefaultTaskSort()}
+	if req == nil {
+		return opts, nil
+	}
+
+	sort, err := taskSortFromProto(req.SortBy, req.SortDirection)
+	if err != nil {
+		return ListOptions{}, err
+	}
+
+	cursor, err := DecodePageCursor(req.PageToken)
+	if err != nil {
+		return ListOptions{}, err
+	}
+	if cursor != nil && cursor.Sort != sort {
+		return ListOptions{}, ErrInvalidPageToken
+	}
+
+	tagsAny, err := NormalizeTags(req.TagsAny)
+	if err != nil {
+		return ListOptions{}, err
+	}
+	tagsAll, err := NormalizeTags(req.TagsAll)
+	if err != nil {
+		return ListOptions{}, err
+	}
+
+	listID, err := ListIDFromProto(req.ListId)
+	if err != nil {
+		return ListOptions{}, err
+	}
+
+	var customFields []CustomFieldFilter
+	for _, filter := range req.CustomFields {
+		customField, err := customFieldFilterFromProto(filter)
+		if err != nil {
+			return ListOptions{}, err
+		}
+		customFields = append(customFields, customField)
+	}
+
+	opts.PageSize = int(req.PageSize)
+	opts.Cursor = cursor
+	opts.Sort = sort
+	opts.Filter = TaskFilter{
+		Completed:     req.Completed,
+		CreatedAfter:  timeFromProto(req.CreatedAfter),
+		CreatedBefore: timeFromProto(req.CreatedBefore),
+		UpdatedAfter:  timeFromProto(req.UpdatedAfter),
+		UpdatedBefore: timeFromProto(req.UpdatedBefore),
+		Query:         strings.TrimSpace(req.Query),
+		Overdue:       req.Overdue,
+		TagsAny:       tagsAny,
+		TagsAll:       tagsAll,
+		ListID:        listID,
+		AssignedToMe:  req.AssignedToMe,
+		CustomFields:  customFields,
+
+		IncludeDeleted: req.IncludeDeleted,
+	}
+
+	return opts, nil
+}
+
+func taskSortFromProto(field pb.TaskSortField, direction pb.SortDirection) (TaskSort, error) {
+	sort := DefaultTaskSort()
+
+	switch field {
+	case pb.TaskSortField_TASK_SORT_FIELD_UNSPECIFIED, pb.TaskSortField_TASK_SORT_FIELD_CREATED_AT:
+		sort.Field = SortByCreatedAt
+	case pb.TaskSortField_TASK_SORT_FIELD_UPDATED_AT:
+		sort.Field = SortByUpdatedAt
+	case pb.TaskSortField_TASK_SORT_FIELD_TITLE:
+		sort.Field = SortByTitle
+	case pb.TaskSortField_TASK_SORT_FIELD_POSITION:
+		sort.Field = SortByPosition
+	case pb.TaskSortField_TASK_SORT_FIELD_PRIORITY:
+		sort.Field = SortByPriority
+	default:
+		return TaskSort{}, ErrInvalidSort
+	}
+
+	switch direction {
+	case pb.SortDirection_SORT_DIRECTION_UNSPECIFIED:
+		// The manual order reads top to bottom.
+		sort.Desc = sort.Field != SortByPosition
+	case pb.SortDirection_SORT_DIRECTION_DESC:
+		sort.Desc = true
+	case pb.SortDirection_SORT_DIRECTION_ASC:
+		sort.Desc = false
+	default:
+		return TaskSort{}, ErrInvalidSort
+	}
+
+	return sort, nil
+}
+
+func TagCountsToProto(counts []*TagCount) []*pb.TagCount {
+	protoCounts := make([]*pb.TagCount, len(counts))
+	for i, count := range counts {
+		protoCounts[i] = &pb.TagCount{
+			Tag:   count.Tag,
+			Count: count.Count,
+		}
+	}
+	return protoCounts
+}
+
+func OccurrencesToProto(occurrences []*Occurrence) []*pb.Occurrence {
+	protoOccurrences := make([]*pb.Occurrence, len(occurrences))
+	for i, occurrence := range occurrences {
+		protoOccurrences[i] = &pb.Occurrence{
+			Task:         TaskToProto(occurrence.Task),
+			OccursAt:     timestamppb.New(occurrence.OccursAt),
+			Materialized: occurrence.Materialized,
+		}
+	}
+	return protoOccurrences
+}
+
+// ListOccurrencesRequestFromProto returns the window and the page limit.
+func ListOccurrencesRequestFromProto(req *pb.ListOccurrencesRequest) (start, end time.Time, limit int, err error) {
+	if req == nil || req.Start == nil || req.End == nil {
+		return time.Time{}, time.Time{}, 0, ErrInvalidFilter
+	}
+	start, end = req.Start.AsTime(), req.End.AsTime()
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, 0, ErrInvalidFilter
+	}
+	return start, end, PageLimit(int(req.PageSize)), nil
+}
+
+func TaskListToProto(list *TaskList) *pb.TaskList {
+	if list == nil {
+		return nil
+	}
+
+	return &pb.TaskList{
+		Id:          list.ID.String(),
+		TenantId:    list.TenantID,
+		OwnerId:     list.OwnerID,
+		Name:        list.Name,
+		Description: list.Description,
+		CreatedAt:   timestamppb.New(list.CreatedAt),
+		UpdatedAt:   timestamppb.New(list.UpdatedAt),
+	}
+}
+
+func TaskListsToProto(lists []*TaskList) []*pb.TaskList {
+	protoLists := make([]*pb.TaskList, len(lists))
+	for i, list := range lists {
+		protoLists[i] = TaskListToProto(list)
+	}
+	return protoLists
+}
+
+func CreateTaskListRequestFromProto(req *pb.CreateTaskListRequest) (name, description string) {
+	if req == nil {
+		return "", ""
+	}
+	return strings.TrimSpace(req.Name), req.Description
+}
+
+func UpdateTaskListRequestFromProto(req *pb.UpdateTaskListRequest) (uuid.UUID, TaskListUpdate, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return uuid.Nil, TaskListUpdate{}, err
+	}
+
+	update := TaskListUpdate{Description: req.Description}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		update.Name = &name
+	}
+	return id, update, nil
+}
+
+func GetTaskListRequestFromProto(req *pb.GetTaskListRequest) (uuid.UUID, error) {
+	return uuid.Parse(req.GetId())
+}
+
+func DeleteTaskListRequestFromProto(req *pb.DeleteTaskListRequest) (uuid.UUID, error) {
+	return uuid.Parse(req.GetId())
+}
+
+// ListTaskListsRequestFromProto returns the page size and the position to
+// continue after.
+func ListTaskListsRequestFromProto(req *pb.ListTaskListsRequest) (int, *PageCursor, error) {
+	cursor, err := DecodePageCursor(req.GetPageToken())
+	if err != nil {
+		return 0, nil, err
+	}
+	if cursor != nil && cursor.Sort != (TaskSort{Field: SortByCreatedAt}) {
+		return 0, nil, ErrInvalidPageToken
+	}
+	return PageLimit(int(req.GetPageSize())), cursor, nil
+}
+
+func CustomFieldToProto(field *CustomField) *pb.CustomField {
+	if field == nil {
+		return nil
+	}
+
+	return &pb.CustomField{
+		Id:        field.ID.String(),
+		ListId:    field.ListID.String(),
+		Key:       field.Key,
+		Type:      customFieldTypeToProto(field.Type),
+		Options:   field.Options,
+		CreatedAt: timestamppb.New(field.CreatedAt),
+	}
+}
+
+func CustomFieldsToProto(fields []*CustomField) []*pb.CustomField {
+	protoFields := make([]*pb.CustomField, len(fields))
+	for i, field := range fields {
+		protoFields[i] = CustomFieldToProto(field)
+	}
+	return protoFields
+}
+
+func CreateCustomFieldRequestFromProto(req *pb.CreateCustomFieldRequest) (*CustomField, error) {
+	listID, err := uuid.Parse(req.GetListId())
+	if err != nil {
+		return nil, ErrInvalidListID
+	}
+	return NewCustomField(listID, req.GetKey(), customFieldTypeFromProto(req.GetType()), req.GetOptions())
+}
+
+func DeleteCustomFieldRequestFromProto(req *pb.DeleteCustomFieldRequest) (uuid.UUID, string, error) {
+	listID, err := uuid.Parse(req.GetListId())
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidListID
+	}
+	return listID, req.GetKey(), nil
+}
 
+func customFieldTypeToProto(fieldType CustomFieldType) pb.CustomFieldType {
+	switch fieldType {
+	case CustomFieldString:
+		return pb.CustomFieldType_CUSTOM_FIELD_TYPE_STRING
+	case CustomFieldNumber:
+		return pb.CustomFieldType_CUSTOM_FIELD_TYPE_NUMBER
+	case CustomFieldDate:
+		return pb.CustomFieldType_CUSTOM_FIELD_TYPE_DATE
+	case CustomFieldEnum:
+		return pb.CustomFieldType_CUSTOM_FIELD_TYPE_ENUM
+	default:
+		return pb.CustomFieldType_CUSTOM_FIELD_TYPE_UNSPECIFIED
+	}
+}
+
+func customFieldTypeFromProto(fieldType pb.CustomFieldType) CustomFieldType {
+	switch fieldType {
+	case pb.CustomFieldType_CUSTOM_FIELD_TYPE_STRING:
+		return CustomFieldString
+	case pb.CustomFieldType_CUSTOM_FIELD_TYPE_NUMBER:
+		return CustomFieldNumber
+	case pb.CustomFieldType_CUSTOM_FIELD_TYPE_DATE:
+		return CustomFieldDate
+	case pb.CustomFieldType_CUSTOM_FIELD_TYPE_ENUM:
+		return CustomFieldEnum
+	default:
+		return ""
+	}
+}
+
+func CustomFieldValuesToProto(values map[string]interface{}) map[string]*pb.CustomFieldValue {
+	if len(values) == 0 {
+		return nil
+	}
+
+	protoValues := make(map[string]*pb.CustomFieldValue, len(values))
+	for key, value := range values {
+		switch v := value.(type) {
+		case float64:
+			protoValues[key] = &pb.CustomFieldValue{Value: &pb.CustomFieldValue_NumberValue{NumberValue: v}}
+		case string:
+			protoValues[key] = &pb.CustomFieldValue{Value: &pb.CustomFieldValue_StringValue{StringValue: v}}
+		}
+	}
+	return protoValues
+}
+
+// CustomFieldValuesFromProto returns the values as float64 or string. It
+// only checks that each holds a value; whether the fields accept them is up
+// to CustomFieldSchema.
+func CustomFieldValuesFromProto(protoValues map[string]*pb.CustomFieldValue) (map[string]interface{}, error) {
+	if len(protoValues) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]interface{}, len(protoValues))
+	for key, protoValue := range protoValues {
+		value, err := customFieldValueFromProto(protoValue)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+func customFieldValueFromProto(value *pb.CustomFieldValue) (interface{}, error) {
+	switch v := value.GetValue().(type) {
+	case *pb.CustomFieldValue_NumberValue:
+		return v.NumberValue, nil
+	case *pb.CustomFieldValue_StringValue:
+		return v.StringValue, nil
+	default:
+		return nil, ErrInvalidCustomFieldValue
+	}
+}
+
+func customFieldFilterFromProto(filter *pb.CustomFieldFilter) (CustomFieldFilter, error) {
+	result := CustomFieldFilter{Key: filter.GetKey()}
+
+	switch filter.GetOperator() {
+	case pb.CustomFieldOperator_CUSTOM_FIELD_OPERATOR_EQ:
+		result.Op = CustomFieldEq
+	case pb.CustomFieldOperator_CUSTOM_FIELD_OPERATOR_LT:
+		result.Op = CustomFieldLt
+	case pb.CustomFieldOperator_CUSTOM_FIELD_OPERATOR_LTE:
+		result.Op = CustomFieldLte
+	case pb.CustomFieldOperator_CUSTOM_FIELD_OPERATOR_GT:
+		result.Op = CustomFieldGt
+	case pb.CustomFieldOperator_CUSTOM_FIELD_OPERATOR_GTE:
+		result.Op = CustomFieldGte
+	case pb.CustomFieldOperator_CUSTOM_FIELD_OPERATOR_SET:
+		result.Op = CustomFieldSet
+	default:
+		return CustomFieldFilter{}, ErrInvalidCustomFieldFilter
+	}
+
+	if filter.GetValue() != nil {
+		value, err := customFieldValueFromProto(filter.GetValue())
+		if err != nil {
+			return CustomFieldFilter{}, ErrInvalidCustomFieldFilter
+		}
+		result.Value = value
+	}
+	return result, nil
+}
+
+func TaskTemplateToProto(template *TaskTemplate) *pb.TaskTemplate {
+	if template == nil {
+		return nil
+	}
+
+	subtasks := make([]*pb.TemplateSubtask, len(template.Subtasks))
+	for i, subtask := range template.Subtasks {
+		subtasks[i] = &pb.TemplateSubtask{
+			Title:       subtask.Title,
+			Description: subtask.Description,
+			Items:       subtask.Items,
+		}
+	}
+
+	return &pb.TaskTemplate{
+		Id:           template.ID.String(),
+		TenantId:     template.TenantID,
+		OwnerId:      template.OwnerID,
+		Title:        template.Title,
+		Description:  template.Description,
+		Priority:     pb.TaskPriority(template.Priority),
+		Tags:         template.Tags,
+		ListId:       uuidToProto(template.ListID),
+		CustomFields: CustomFieldValuesToProto(template.CustomFields),
+		Items:        template.Items,
+		Subtasks:     subtasks,
+		CreatedAt:    timestamppb.New(template.CreatedAt),
+		UpdatedAt:    timestamppb.New(template.UpdatedAt),
+	}
+}
+
+func TaskTemplatesToProto(templates []*TaskTemplate) []*pb.TaskTemplate {
+	protoTemplates := make([]*pb.TaskTemplate, len(templates))
+	for i, template := range templates {
+		protoTemplates[i] = TaskTemplateToProto(template)
+	}
+	return protoTemplates
+}
+
+// CreateTemplateRequestFromProto returns the template the request
+// describes. Its tags are left for the caller to normalize.
+func CreateTemplateRequestFromProto(req *pb.CreateTemplateRequest) (*TaskTemplate, error) {
+	return templateFromProto(req.GetTitle(), req.GetDescription(), req.GetPriority(), req.GetTags(),
+		req.GetListId(), req.GetCustomFields(), req.GetItems(), req.GetSubtasks())
+}
+
+// UpdateTemplateRequestFromProto returns the template that replaces the one
+// with the id of the request. Its tags are left for the caller to
+// normalize.
+func UpdateTemplateRequestFromProto(req *pb.UpdateTemplateRequest) (*TaskTemplate, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, ErrInvalidTemplateID
+	}
+
+	template, err := templateFromProto(req.GetTitle(), req.GetDescription(), req.GetPriority(), req.GetTags(),
+		req.GetListId(), req.GetCustomFields(), req.GetItems(), req.GetSubtasks())
+	if err != nil {
+		return nil, err
+	}
+	template.ID = id
+	return template, nil
+}
+
+func templateFromProto(title, description string, priority pb.TaskPriority, tags []string, listID string,
+	customFields map[string]*pb.CustomFieldValue, items []string, subtasks []*pb.TemplateSubtask) (*TaskTemplate, error) {
+	template := NewTaskTemplate(title, description)
+	template.Priority = Priority(priority)
+	template.Tags = tags
+
+	var err error
+	if template.ListID, err = ListIDFromProto(listID); err != nil {
+		return nil, err
+	}
+	if template.CustomFields, err = CustomFieldValuesFromProto(customFields); err != nil {
+		return nil, err
+	}
+	template.Items = append(template.Items, items...)
+
+	for _, subtask := range subtasks {
+		template.Subtasks = append(template.Subtasks, TemplateSubtask{
+			Title:       subtask.GetTitle(),
+			Description: subtask.GetDescription(),
+			Items:       append([]string{}, subtask.GetItems()...),
+		})
+	}
+	return template, nil
+}
+
+func GetTemplateRequestFromProto(req *pb.GetTemplateRequest) (uuid.UUID, error) {
+	return uuid.Parse(req.GetId())
+}
+
+func DeleteTemplateRequestFromProto(req *pb.DeleteTemplateRequest) (uuid.UUID, error) {
+	return uuid.Parse(req.GetId())
+}
+
+// ListTemplatesRequestFromProto returns the page size and the position to
+// continue after.
+func ListTemplatesRequestFromProto(req *pb.ListTemplatesRequest) (int, *PageCursor, error) {
+	cursor, err := DecodePageCursor(req.GetPageToken())
+	if err != nil {
+		return 0, nil, err
+	}
+	if cursor != nil && cursor.Sort != (TaskSort{Field: SortByCreatedAt}) {
+		return 0, nil, ErrInvalidPageToken
+	}
+	return PageLimit(int(req.GetPageSize())), cursor, nil
+}
+
+func InstantiateTemplateRequestFromProto(req *pb.InstantiateTemplateRequest) (uuid.UUID, map[string]string, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return id, req.GetParams(), nil
+}
+
+func DependencyRequestFromProto(req *pb.DependencyRequest) (Dependency, error) {
+	taskID, err := uuid.Parse(req.GetTaskId())
+	if err != nil {
+		return Dependency{}, err
+	}
+	blockerID, err := uuid.Parse(req.GetBlockerId())
+	if err != nil {
+		return Dependency{}, err
+	}
+	return NewDependency(taskID, blockerID)
+}
+
+func TaskGraphToProto(graph *TaskGraph) *pb.GetTaskGraphResponse {
+	dependencies := make([]*pb.TaskDependency, len(graph.Dependencies))
+	for i, dependency := range graph.Dependencies {
+		dependencies[i] = &pb.TaskDependency{
+			TaskId:    dependency.TaskID.String(),
+			BlockerId: dependency.BlockerID.String(),
+		}
+	}
+
+	return &pb.GetTaskGraphResponse{
+		Tasks:        TasksToProto(graph.Tasks),
+		Dependencies: dependencies,
+		Truncated:    graph.Truncated,
+	}
+}
+
+func TaskTreeToProto(tree *TaskTree) *pb.GetTaskTreeResponse {
+	return &pb.GetTaskTreeResponse{
+		Root:      taskNodeToProto(tree.Root),
+		Truncated: tree.Truncated,
+	}
+}
+
+func taskNodeToProto(node *TaskNode) *pb.TaskTreeNode {
+	if node == nil {
+		return nil
+	}
+
+	children := make([]*pb.TaskTreeNode, len(node.Children))
+	for i, child := range node.Children {
+		children[i] = taskNodeToProto(child)
+	}
+	return &pb.TaskTreeNode{
+		Task:     TaskToProto(node.Task),
+		Children: children,
+	}
+}
+
+func ShareTaskRequestFromProto(req *pb.ShareTaskRequest) (TaskGrant, error) {
+	taskID, err := uuid.Parse(req.GetTaskId())
+	if err != nil {
+		return TaskGrant{}, err
+	}
+	return NewTaskGrant(taskID, strings.TrimSpace(req.GetUserId()), permissionFromProto(req.GetPermission()))
+}
+
+// TaskUserFromProto parses the task and user of an unshare, assign or
+// unassign request.
+func TaskUserFromProto(taskID, userID string) (uuid.UUID, string, error) {
+	id, err := uuid.Parse(taskID)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return uuid.Nil, "", ErrInvalidUserID
+	}
+	return id, userID, nil
+}
+
+func TaskAccessToProto(access *TaskAccess) *pb.TaskAccess {
+	grants := make([]*pb.TaskGrant, len(access.Grants))
+	for i, grant := range access.Grants {
+		grants[i] = &pb.TaskGrant{
+			UserId:     grant.UserID,
+			Permission: permissionToProto(grant.Permission),
+			CreatedAt:  timestamppb.New(grant.CreatedAt),
+		}
+	}
+
+	return &pb.TaskAccess{
+		TaskId:      access.TaskID.String(),
+		OwnerId:     access.OwnerID,
+		Grants:      grants,
+		AssigneeIds: access.AssigneeIDs,
+	}
+}
+
+func permissionToProto(permission Permission) pb.TaskPermission {
+	switch permission {
+	case PermissionRead:
+		return pb.TaskPermission_TASK_PERMISSION_READ
+	case PermissionEdit:
+		return pb.TaskPermission_TASK_PERMISSION_EDIT
+	default:
+		return pb.TaskPermission_TASK_PERMISSION_UNSPECIFIED
+	}
+}
+
+func permissionFromProto(permission pb.TaskPermission) Permission {
+	switch permission {
+	case pb.TaskPermission_TASK_PERMISSION_READ:
+		return PermissionRead
+	case pb.TaskPermission_TASK_PERMISSION_EDIT:
+		return PermissionEdit
+	default:
+		return PermissionNone
+	}
+}
+
+func CommentToProto(comment *Comment) *pb.Comment {
+	if comment == nil {
+		return nil
+	}
+
+	return &pb.Comment{
+		Id:        comment.ID.String(),
+		TaskId:    comment.TaskID.String(),
+		AuthorId:  comment.AuthorID,
+		Body:      comment.Body,
+		CreatedAt: timestamppb.New(comment.CreatedAt),
+		EditedAt:  timestampToProto(comment.EditedAt),
+	}
+}
+
+func CommentsToProto(comments []*Comment) []*pb.Comment {
+	protoComments := make([]*pb.Comment, len(comments))
+	for i, comment := range comments {
+		protoComments[i] = CommentToProto(comment)
+	}
+	return protoComments
+}
+
+func AddCommentRequestFromProto(req *pb.AddCommentRequest) (uuid.UUID, string, error) {
+	taskID, err := uuid.Parse(req.GetTaskId())
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	body, err := CommentBody(req.GetBody())
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return taskID, body, nil
+}
+
+// CommentIDsFromProto parses the task and comment of an edit or delete
+// request.
+func CommentIDsFromProto(taskID, commentID string) (uuid.UUID, uuid.UUID, error) {
+	task, err := uuid.Parse(taskID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	comment, err := uuid.Parse(commentID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidCommentID
+	}
+	return task, comment, nil
+}
+
+func EditCommentRequestFromProto(req *pb.EditCommentRequest) (taskID, commentID uuid.UUID, body string, err error) {
+	taskID, commentID, err = CommentIDsFromProto(req.GetTaskId(), req.GetCommentId())
+	if err != nil {
+		return uuid.Nil, uuid.Nil, "", err
+	}
+	body, err = CommentBody(req.GetBody())
+	if err != nil {
+		return uuid.Nil, uuid.Nil, "", err
+	}
+	return taskID, commentID, body, nil
+}
+
+// ListCommentsRequestFromProto returns the task, the page size and the
+// position to continue after.
+func ListCommentsRequestFromProto(req *pb.ListCommentsRequest) (uuid.UUID, int, *PageCursor, error) {
+	taskID, err := uuid.Parse(req.GetTaskId())
+	if err != nil {
+		return uuid.Nil, 0, nil, err
+	}
+	cursor, err := DecodePageCursor(req.GetPageToken())
+	if err != nil {
+		return uuid.Nil, 0, nil, err
+	}
+	if cursor != nil && cursor.Sort != (TaskSort{Field: SortByCreatedAt}) {
+		return uuid.Nil, 0, nil, ErrInvalidPageToken
+	}
+	return taskID, PageLimit(int(req.GetPageSize())), cursor, nil
+}
+
+func AttachmentToProto(attachment *Attachment) *pb.Attachment {
+	if attachment == nil {
+		return nil
+	}
+
+	return &pb.Attachment{
+		Id:          attachment.ID.String(),
+		TaskId:      attachment.TaskID.String(),
+		UploaderId:  attachment.UploaderID,
+		Name:        attachment.Name,
+		Size:        attachment.Size,
+		ContentType: attachment.ContentType,
+		Checksum:    attachment.Checksum,
+		CreatedAt:   timestamppb.New(attachment.CreatedAt),
+	}
+}
+
+func AttachmentsToProto(attachments []*Attachment) []*pb.Attachment {
+	protoAttachments := make([]*pb.Attachment, len(attachments))
+	for i, attachment := range attachments {
+		protoAttachments[i] = AttachmentToProto(attachment)
+	}
+	return protoAttachments
+}
+
+func AttachmentMetadataFromProto(metadata *pb.AttachmentMetadata) (*Attachment, error) {
+	taskID, err := uuid.Parse(metadata.GetTaskId())
+	if err != nil {
+		return nil, err
+	}
+	return NewAttachment(taskID, metadata.GetName(), metadata.GetContentType())
+}
+
+// AttachmentIDsFromProto parses the task and attachment of a download or
+// delete request.
+func AttachmentIDsFromProto(taskID, attachmentID string) (uuid.UUID, uuid.UUID, error) {
+	task, err := uuid.Parse(taskID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	attachment, err := uuid.Parse(attachmentID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidAttachmentID
+	}
+	return task, attachment, nil
+}
+
+func uuidToProto(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func uuidFromProto(id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func timestampToProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func timeFromProto(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
@@ -0,0 +1,201 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxCustomFieldsPerList = 50
+	MaxCustomFieldOptions  = 100
+	MaxCustomFieldLength   = 1000
+
+	// CustomFieldDateLayout is the format of date values, which compare in
+	// date order as plain strings.
+	CustomFieldDateLayout = "2006-01-02"
+)
+
+var (
+	ErrInvalidCustomField       = errors.New("invalid custom field definition")
+	ErrInvalidCustomFieldValue  = errors.New("invalid custom field value")
+	ErrInvalidCustomFieldFilter = errors.New("invalid custom field filter")
+)
+
+// customFieldKey keeps keys safe to embed in JSON paths and stable across
+// clients.
+var customFieldKey = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+type CustomFieldType string
+
+const (
+	CustomFieldString CustomFieldType = "string"
+	CustomFieldNumber CustomFieldType = "number"
+	CustomFieldDate   CustomFieldType = "date"
+	CustomFieldEnum   CustomFieldType = "enum"
+)
+
+// CustomField defines a field that the tasks of a task list may carry. The
+// values live on the tasks in CustomFields: numbers as float64 and every
+// other type as a string.
+type CustomField struct {
+	ID     uuid.UUID
+	ListID uuid.UUID
+	Key    string
+	Type   CustomFieldType
+	// Options are the values an enum field accepts.
+	Options   []string
+	CreatedAt time.Time
+}
+
+func NewCustomField(listID uuid.UUID, key string, fieldType CustomFieldType, options []string) (*CustomField, error) {
+	if !customFieldKey.MatchString(key) {
+		return nil, ErrInvalidCustomField
+	}
+
+	switch fieldType {
+	case CustomFieldString, CustomFieldNumber, CustomFieldDate:
+		if len(options) > 0 {
+			return nil, ErrInvalidCustomField
+		}
+		options = []string{}
+	case CustomFieldEnum:
+		if len(options) == 0 || len(options) > MaxCustomFieldOptions {
+			return nil, ErrInvalidCustomField
+		}
+		seen := make(map[string]bool, len(options))
+		for _, option := range options {
+			if option == "" || len(option) > MaxCustomFieldLength || seen[option] {
+				return nil, ErrInvalidCustomField
+			}
+			seen[option] = true
+		}
+	default:
+		return nil, ErrInvalidCustomField
+	}
+
+	return &CustomField{
+		ID:        uuid.New(),
+		ListID:    listID,
+		Key:       key,
+		Type:      fieldType,
+		Options:   options,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// ValidateValue checks that value is a string or float64 the field accepts.
+func (f *CustomField) ValidateValue(value interface{}) error {
+	switch v := value.(type) {
+	case float64:
+		if f.Type == CustomFieldNumber && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return nil
+		}
+	case string:
+		switch f.Type {
+		case CustomFieldString:
+			if len(v) <= MaxCustomFieldLength {
+				return nil
+			}
+		case CustomFieldDate:
+			if _, err := time.Parse(CustomFieldDateLayout, v); err == nil {
+				return nil
+			}
+		case CustomFieldEnum:
+			if slices.Contains(f.Options, v) {
+				return nil
+			}
+		}
+	}
+	return ErrInvalidCustomFieldValue
+}
+
+// CustomFieldSchema is the set of fields defined for one task list, by key.
+// The zero schema belongs to tasks filed in no list, which carry no custom
+// fields.
+type CustomFieldSchema map[string]*CustomField
+
+func NewCustomFieldSchema(fields []*CustomField) CustomFieldSchema {
+	schema := make(CustomFieldSchema, len(fields))
+	for _, field := range fields {
+		schema[field.Key] = field
+	}
+	return schema
+}
+
+// Validate checks every value against the field of its key.
+func (s CustomFieldSchema) Validate(values map[string]interface{}) error {
+	for key, value := range values {
+		field, ok := s[key]
+		if !ok {
+			return ErrInvalidCustomFieldValue
+		}
+		if err := field.ValidateValue(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateFilter checks that the filter names a field of the schema, uses
+// an operator that suits its type and compares against a value it accepts.
+func (s CustomFieldSchema) ValidateFilter(filter CustomFieldFilter) error {
+	field, ok := s[filter.Key]
+	if !ok {
+		return ErrInvalidCustomFieldFilter
+	}
+
+	switch filter.Op {
+	case CustomFieldSet:
+		if filter.Value != nil {
+			return ErrInvalidCustomFieldFilter
+		}
+		return nil
+	case CustomFieldEq:
+	case CustomFieldLt, CustomFieldLte, CustomFieldGt, CustomFieldGte:
+		if field.Type != CustomFieldNumber && field.Type != CustomFieldDate {
+			return ErrInvalidCustomFieldFilter
+		}
+	default:
+		return ErrInvalidCustomFieldFilter
+	}
+
+	if field.ValidateValue(filter.Value) != nil {
+		return ErrInvalidCustomFieldFilter
+	}
+	return nil
+}
+
+type CustomFieldOp string
+
+const (
+	CustomFieldEq  CustomFieldOp = "eq"
+	CustomFieldLt  CustomFieldOp = "lt"
+	CustomFieldLte CustomFieldOp = "lte"
+	CustomFieldGt  CustomFieldOp = "gt"
+	CustomFieldGte CustomFieldOp = "gte"
+	// CustomFieldSet keeps tasks that have any value for the field.
+	CustomFieldSet CustomFieldOp = "set"
+)
+
+// CustomFieldFilter compares the value of a custom field against Value,
+// which is a float64 for number fields and a string otherwise.
+type CustomFieldFilter struct {
+	Key   string        `json:"key"`
+	Op    CustomFieldOp `json:"op"`
+	Value interface{}   `json:"value,omitempty"`
+}
+
+// CopyCustomFields returns a copy of values that is never nil, as the
+// column does not take NULL.
+func CopyCustomFields(values map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
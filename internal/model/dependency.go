@@ -0,0 +1,34 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// MaxGraphTasks bounds the tasks GetTaskGraph returns.
+const MaxGraphTasks = 1000
+
+var ErrInvalidDependency = errors.New("a task cannot depend on itself")
+
+// Dependency records that TaskID cannot be completed while BlockerID is
+// open.
+type Dependency struct {
+	TaskID    uuid.UUID
+	BlockerID uuid.UUID
+}
+
+func NewDependency(taskID, blockerID uuid.UUID) (Dependency, error) {
+	if taskID == blockerID {
+		return Dependency{}, ErrInvalidDependency
+	}
+	return Dependency{TaskID: taskID, BlockerID: blockerID}, nil
+}
+
+// TaskGraph is the part of the dependency DAG connected to one task: every
+// task it transitively depends on or that transitively depends on it.
+type TaskGraph struct {
+	Tasks        []*Task
+	Dependencies []Dependency
+	Truncated    bool
+}
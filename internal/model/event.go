@@ -0,0 +1,65 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrInvalidResumeToken = errors.New("invalid resume token")
+
+type TaskEventType string
+
+const (
+	TaskCreated  TaskEventType = "created"
+	TaskUpdated  TaskEventType = "updated"
+	TaskDeleted  TaskEventType = "deleted"
+	TaskRestored TaskEventType = "restored"
+	TaskPurged   TaskEventType = "purged"
+)
+
+type TaskEvent struct {
+	Type       TaskEventType
+	Task       *Task
+	Position   EventPosition
+	OccurredAt time.Time
+}
+
+// EventPosition orders events by the id of the writing transaction and then
+// by sequence. Reading only events of transactions older than every one
+// still in flight guarantees that no event ever appears behind a position
+// already handed out, which makes the position safe to resume from.
+type EventPosition struct {
+	TxID int64 `json:"x"`
+	Seq  int64 `json:"s"`
+}
+
+func (p EventPosition) Encode() string {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeEventPosition(token string) (*EventPosition, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidResumeToken
+	}
+
+	var position EventPosition
+	if err := json.Unmarshal(data, &position); err != nil {
+		return nil, ErrInvalidResumeToken
+	}
+	if position.TxID <= 0 || position.Seq <= 0 {
+		return nil, ErrInvalidResumeToken
+	}
+
+	return &position, nil
+}
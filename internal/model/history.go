@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskHistoryEntry is one audited change. Before is nil for a creation and
+// After is nil for a purge.
+type TaskHistoryEntry struct {
+	ID        int64
+	TaskID    uuid.UUID
+	Action    TaskEventType
+	ActorID   string
+	RequestID string
+	Before    *Task
+	After     *Task
+	CreatedAt time.Time
+}
+
+type HistoryOptions struct {
+	PageSize int
+	Cursor   *HistoryCursor
+}
+
+func (o HistoryOptions) Limit() int {
+	return PageLimit(o.PageSize)
+}
+
+type HistoryPage struct {
+	Entries    []*TaskHistoryEntry
+	NextCursor *HistoryCursor
+}
+
+// HistoryCursor is the id of the last entry returned; entries are listed
+// newest first.
+type HistoryCursor struct {
+	ID int64 `json:"i"`
+}
+
+func (c *HistoryCursor) Encode() string {
+	if c == nil {
+		return ""
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeHistoryCursor(token string) (*HistoryCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	var cursor HistoryCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	if cursor.ID <= 0 {
+		return nil, ErrInvalidPageToken
+	}
+
+	return &cursor, nil
+}
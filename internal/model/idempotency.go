@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyRecord maps a caller's idempotency key to the request it was
+// first used with and the response that was returned for it.
+type IdempotencyRecord struct {
+	Key         string
+	Operation   string
+	RequestHash string
+	Response    []byte
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,213 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+var (
+	ErrInvalidPageToken = errors.New("invalid page token")
+	ErrInvalidSort      = errors.New("invalid sort field or direction")
+	ErrInvalidFilter    = errors.New("invalid filter")
+	ErrInvalidMove      = errors.New("invalid move target")
+	ErrInvalidListID    = errors.New("invalid task list id")
+)
+
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByUpdatedAt SortField = "updated_at"
+	SortByTitle     SortField = "title"
+	SortByPosition  SortField = "position"
+	SortByPriority  SortField = "priority"
+)
+
+type TaskSort struct {
+	Field SortField `json:"field"`
+	Desc  bool      `json:"desc"`
+}
+
+func DefaultTaskSort() TaskSort {
+	return TaskSort{Field: SortByCreatedAt, Desc: true}
+}
+
+type TaskFilter struct {
+	Completed     *bool      `json:"completed,omitempty"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	UpdatedAfter  *time.Time `json:"updated_after,omitempty"`
+	UpdatedBefore *time.Time `json:"updated_before,omitempty"`
+	Query         string     `json:"query,omitempty"`
+	// Overdue keeps open tasks whose due date has passed.
+	Overdue bool `json:"overdue,omitempty"`
+	// TagsAny keeps tasks with at least one of the tags, TagsAll those with
+	// every one of them.
+	TagsAny []string `json:"tags_any,omitempty"`
+	TagsAll []string `json:"tags_all,omitempty"`
+	// ListID keeps the tasks filed in one task list.
+	ListID *uuid.UUID `json:"list_id,omitempty"`
+	// AssignedToMe keeps the tasks of any owner that the caller is
+	// assigned to instead of the caller's own tasks.
+	AssignedToMe bool `json:"assigned_to_me,omitempty"`
+	// CustomFields keeps the tasks matching every filter. It requires
+	// ListID, as the filters are checked against the fields of that list.
+	CustomFields []CustomFieldFilter `json:"custom_fields,omitempty"`
+
+	IncludeDeleted bool `json:"include_deleted,omitempty"`
+}
+
+func (f TaskFilter) Validate() error {
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && f.CreatedAfter.After(*f.CreatedBefore) {
+		return ErrInvalidFilter
+	}
+	if f.UpdatedAfter != nil && f.UpdatedBefore != nil && f.UpdatedAfter.After(*f.UpdatedBefore) {
+		return ErrInvalidFilter
+	}
+	if len(f.CustomFields) > 0 && f.ListID == nil {
+		return ErrInvalidCustomFieldFilter
+	}
+	return nil
+}
+
+type ListOptions struct {
+	PageSize int         `json:"page_size"`
+	Cursor   *PageCursor `json:"cursor,omitempty"`
+	Filter   TaskFilter  `json:"filter"`
+	Sort     TaskSort    `json:"sort"`
+}
+
+type TaskPage struct {
+	Tasks      []*Task
+	NextCursor *PageCursor
+}
+
+// PageCursor is a keyset position on (sort column, id) of the last task
+// returned, so pages stay stable while new rows are inserted.
+type PageCursor struct {
+	Sort  TaskSort  `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"i"`
+}
+
+func CursorAfter(task *Task, sort TaskSort) *PageCursor {
+	cursor := &PageCursor{
+		Sort: sort,
+		ID:   task.ID,
+	}
+
+	switch sort.Field {
+	case SortByUpdatedAt:
+		cursor.Value = task.UpdatedAt.Format(time.RFC3339Nano)
+	case SortByTitle:
+		cursor.Value = task.Title
+	case SortByPosition:
+		cursor.Value = strconv.FormatFloat(task.Position, 'g', -1, 64)
+	case SortByPriority:
+		cursor.Value = strconv.Itoa(int(task.Priority))
+	default:
+		cursor.Value = task.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	return cursor
+}
+
+// SortValue returns the cursor value typed for comparison against the
+// sort column.
+func (c *PageCursor) SortValue() (interface{}, error) {
+	switch c.Sort.Field {
+	case SortByCreatedAt, SortByUpdatedAt:
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, ErrInvalidPageToken
+		}
+		return t, nil
+	case SortByTitle:
+		return c.Value, nil
+	case SortByPosition:
+		position, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return nil, ErrInvalidPageToken
+		}
+		return position, nil
+	case SortByPriority:
+		priority, err := strconv.Atoi(c.Value)
+		if err != nil || !Priority(priority).Valid() {
+			return nil, ErrInvalidPageToken
+		}
+		return priority, nil
+	default:
+		return nil, ErrInvalidPageToken
+	}
+}
+
+func (c *PageCursor) Encode() string {
+	if c == nil {
+		return ""
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodePageCursor(token string) (*PageCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	var cursor PageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	if cursor.ID == uuid.Nil {
+		return nil, ErrInvalidPageToken
+	}
+	if _, err := cursor.SortValue(); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+func (o ListOptions) Limit() int {
+	return PageLimit(o.PageSize)
+}
+
+func PageLimit(pageSize int) int {
+	switch {
+	case pageSize <= 0:
+		return DefaultPageSize
+	case pageSize > MaxPageSize:
+		return MaxPageSize
+	default:
+		return pageSize
+	}
+}
+
+func (o ListOptions) CacheKey() string {
+	o.PageSize = o.Limit()
+
+	data, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OutboxTaskCreated  = "task.created"
+	OutboxTaskUpdated  = "task.updated"
+	OutboxTaskDeleted  = "task.deleted"
+	OutboxTaskRestored = "task.restored"
+	OutboxTaskPurged   = "task.purged"
+)
+
+// OutboxEvent is a domain event recorded in the same transaction as the
+// change it describes and published by the relay afterwards. EventID is
+// stable across retries so that consumers can deduplicate.
+type OutboxEvent struct {
+	ID          int64
+	EventID     uuid.UUID
+	AggregateID uuid.UUID
+	TenantID    string
+	OwnerID     string
+	Type        string
+	Payload     json.RawMessage
+	Attempts    int
+	CreatedAt   time.Time
+}
+
+// RetryPolicy backs off exponentially from BaseDelay up to MaxDelay and
+// gives up after MaxAttempts.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,15 @@
+package model
+
+type Priority int16
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+func (p Priority) Valid() bool {
+	return p >= PriorityNone && p <= PriorityUrgent
+}
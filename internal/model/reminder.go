@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Reminder is sent once the remind_at of an open task has passed. RemindAt
+// is the value that fired, which may differ from Task.RemindAt if the task
+// was changed since. Attempts counts the notifications tried so far,
+// including the current one.
+type Reminder struct {
+	Task     *Task
+	RemindAt time.Time
+	Attempts int
+}
@@ -0,0 +1,340 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRecurrence = errors.New("invalid or unsupported recurrence rule")
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxEmptyPeriods bounds the search for rules that can never produce
+// another occurrence, such as BYMONTH=2;BYMONTHDAY=30.
+const maxEmptyPeriods = 1000
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// RRule is the subset of an RFC 5545 recurrence rule the service supports:
+// FREQ (DAILY to YEARLY), INTERVAL, COUNT, UNTIL, BYDAY without ordinals,
+// BYMONTHDAY and BYMONTH. Weeks start on Monday.
+type RRule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	ByMonth    []time.Month
+}
+
+// ParseRRule parses a rule such as "FREQ=WEEKLY;BYDAY=MO,WE", with or
+// without a leading "RRULE:".
+func ParseRRule(s string) (*RRule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, ErrInvalidRecurrence
+	}
+
+	rule := &RRule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(name)
+		if !ok || value == "" || seen[name] {
+			return nil, ErrInvalidRecurrence
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(value))
+			switch rule.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				err = ErrInvalidRecurrence
+			}
+		case "INTERVAL":
+			rule.Interval, err = parsePositive(value)
+		case "COUNT":
+			rule.Count, err = parsePositive(value)
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(value), ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return nil, ErrInvalidRecurrence
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				day, convErr := strconv.Atoi(v)
+				if convErr != nil || day == 0 || day < -31 || day > 31 {
+					return nil, ErrInvalidRecurrence
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, day)
+			}
+		case "BYMONTH":
+			for _, v := range strings.Split(value, ",") {
+				month, convErr := strconv.Atoi(v)
+				if convErr != nil || month < 1 || month > 12 {
+					return nil, ErrInvalidRecurrence
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(month))
+			}
+		case "WKST":
+			if strings.ToUpper(value) != "MO" {
+				err = ErrInvalidRecurrence
+			}
+		default:
+			err = ErrInvalidRecurrence
+		}
+		if err != nil {
+			return nil, ErrInvalidRecurrence
+		}
+	}
+
+	if rule.Freq == "" || (rule.Count > 0 && rule.Until != nil) {
+		return nil, ErrInvalidRecurrence
+	}
+	return rule, nil
+}
+
+func parsePositive(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, ErrInvalidRecurrence
+	}
+	return n, nil
+}
+
+// parseUntil accepts a UTC date-time or a date; a date includes the whole
+// day.
+func parseUntil(s string) (*time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", s); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("20060102", s)
+	if err != nil {
+		return nil, ErrInvalidRecurrence
+	}
+	t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	return &t, nil
+}
+
+// After returns the first occurrence strictly after t of the series that
+// starts at dtstart, or nil if the series has ended.
+func (r *RRule) After(dtstart, t time.Time) *time.Time {
+	var next *time.Time
+	r.iterate(dtstart, func(occurrence time.Time) bool {
+		if occurrence.After(t) {
+			next = &occurrence
+			return false
+		}
+		return true
+	})
+	return next
+}
+
+// Between returns up to limit occurrences in [start, end) of the series
+// that starts at dtstart.
+func (r *RRule) Between(dtstart, start, end time.Time, limit int) []time.Time {
+	occurrences := []time.Time{}
+	r.iterate(dtstart, func(occurrence time.Time) bool {
+		if !occurrence.Before(end) {
+			return false
+		}
+		if !occurrence.Before(start) {
+			occurrences = append(occurrences, occurrence)
+		}
+		return len(occurrences) < limit
+	})
+	return occurrences
+}
+
+// iterate calls fn with every occurrence in order until fn returns false or
+// the series ends. As in RFC 5545, dtstart is always the first occurrence.
+func (r *RRule) iterate(dtstart time.Time, fn func(time.Time) bool) {
+	emitted := 0
+	emit := func(t time.Time) bool {
+		if r.Until != nil && t.After(*r.Until) {
+			return false
+		}
+		emitted++
+		if !fn(t) {
+			return false
+		}
+		return r.Count == 0 || emitted < r.Count
+	}
+
+	if !emit(dtstart) {
+		return
+	}
+
+	empty := 0
+	for period := 0; empty < maxEmptyPeriods; period++ {
+		candidates := r.period(dtstart, period)
+		if len(candidates) == 0 {
+			empty++
+			continue
+		}
+		empty = 0
+
+		for _, t := range candidates {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// period expands the n-th FREQ*INTERVAL period after the one holding
+// dtstart into its sorted candidate occurrences.
+func (r *RRule) period(dtstart time.Time, n int) []time.Time {
+	step := n * r.Interval
+	y, m, d := dtstart.Date()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+	}
+
+	var candidates []time.Time
+	switch r.Freq {
+	case Daily:
+		candidates = []time.Time{at(y, m, d+step)}
+	case Weekly:
+		monday := d - (int(dtstart.Weekday())+6)%7 + 7*step
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{dtstart.Weekday()}
+		}
+		for _, weekday := range days {
+			candidates = append(candidates, at(y, m, monday+(int(weekday)+6)%7))
+		}
+	case Monthly:
+		first := at(y, m+time.Month(step), 1)
+		candidates = r.monthDays(first, d)
+	case Yearly:
+		year := y + step
+		months := r.ByMonth
+		if len(months) == 0 && len(r.ByDay) > 0 && len(r.ByMonthDay) == 0 {
+			months = []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		} else if len(months) == 0 {
+			months = []time.Month{m}
+		}
+		for _, month := range months {
+			candidates = append(candidates, r.monthDays(at(year, month, 1), d)...)
+		}
+	}
+
+	filtered := candidates[:0]
+	for _, t := range candidates {
+		if r.matches(t) {
+			filtered = append(filtered, t)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool { return filtered[i].Before(filtered[j]) })
+	return filtered
+}
+
+// monthDays expands the month starting at first by BYMONTHDAY, else by
+// BYDAY, else to the day of month of dtstart. Days the month does not have
+// are skipped.
+func (r *RRule) monthDays(first time.Time, day int) []time.Time {
+	last := first.AddDate(0, 1, -1).Day()
+	inMonth := func(d int) (time.Time, bool) {
+		if d < 0 {
+			d = last + 1 + d
+		}
+		if d < 1 || d > last {
+			return time.Time{}, false
+		}
+		return first.AddDate(0, 0, d-1), true
+	}
+
+	var days []time.Time
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, d := range r.ByMonthDay {
+			if t, ok := inMonth(d); ok {
+				days = append(days, t)
+			}
+		}
+	case len(r.ByDay) > 0:
+		for d := 1; d <= last; d++ {
+			t, _ := inMonth(d)
+			days = append(days, t)
+		}
+	default:
+		if t, ok := inMonth(day); ok {
+			days = append(days, t)
+		}
+	}
+	return days
+}
+
+// matches applies the BYxxx parts that limit rather than expand the
+// frequency.
+func (r *RRule) matches(t time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsMonth(r.ByMonth, t.Month()) {
+		return false
+	}
+	if len(r.ByDay) > 0 && !containsWeekday(r.ByDay, t.Weekday()) {
+		return false
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != Monthly && r.Freq != Yearly {
+		last := t.AddDate(0, 1, -t.Day()).Day()
+		found := false
+		for _, d := range r.ByMonthDay {
+			if d == t.Day() || last+1+d == t.Day() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsMonth(months []time.Month, month time.Month) bool {
+	for _, m := range months {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
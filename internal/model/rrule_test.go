@@ -0,0 +1,268 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRRule(t *testing.T) {
+	until := func(year int, month time.Month, day, hour, min, sec, nsec int) *time.Time {
+		u := time.Date(year, month, day, hour, min, sec, nsec, time.UTC)
+		return &u
+	}
+
+	tests := []struct {
+		rule string
+		want *RRule
+	}{
+		{"FREQ=DAILY", &RRule{Freq: Daily, Interval: 1}},
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO,WE", &RRule{Freq: Weekly, Interval: 1, ByDay: []time.Weekday{time.Monday, time.Wednesday}}},
+		{"freq=monthly;interval=2", &RRule{Freq: Monthly, Interval: 2}},
+		{" FREQ=YEARLY;COUNT=3 ", &RRule{Freq: Yearly, Interval: 1, Count: 3}},
+		{"FREQ=DAILY;UNTIL=20240101T120000Z", &RRule{Freq: Daily, Interval: 1, Until: until(2024, 1, 1, 12, 0, 0, 0)}},
+		{"FREQ=DAILY;UNTIL=20240101", &RRule{Freq: Daily, Interval: 1, Until: until(2024, 1, 1, 23, 59, 59, 999999999)}},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1", &RRule{Freq: Monthly, Interval: 1, ByMonthDay: []int{1, -1}}},
+		{"FREQ=YEARLY;BYMONTH=2,8", &RRule{Freq: Yearly, Interval: 1, ByMonth: []time.Month{time.February, time.August}}},
+		{"FREQ=WEEKLY;WKST=MO", &RRule{Freq: Weekly, Interval: 1}},
+
+		{"", nil},
+		{"RRULE:", nil},
+		{"INTERVAL=2", nil},
+		{"FREQ", nil},
+		{"FREQ=", nil},
+		{"FREQ=HOURLY", nil},
+		{"FREQ=DAILY;FREQ=WEEKLY", nil},
+		{"FREQ=DAILY;INTERVAL=0", nil},
+		{"FREQ=DAILY;INTERVAL=-1", nil},
+		{"FREQ=DAILY;INTERVAL=two", nil},
+		{"FREQ=DAILY;COUNT=0", nil},
+		{"FREQ=DAILY;UNTIL=2024-01-01", nil},
+		{"FREQ=DAILY;UNTIL=20240101T120000", nil},
+		{"FREQ=DAILY;COUNT=2;UNTIL=20240101", nil},
+		{"FREQ=WEEKLY;BYDAY=1MO", nil},
+		{"FREQ=WEEKLY;BYDAY=XX", nil},
+		{"FREQ=WEEKLY;BYDAY=MO,", nil},
+		{"FREQ=MONTHLY;BYMONTHDAY=0", nil},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", nil},
+		{"FREQ=YEARLY;BYMONTH=13", nil},
+		{"FREQ=WEEKLY;WKST=SU", nil},
+		{"FREQ=MONTHLY;BYSETPOS=-1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			got, err := ParseRRule(tt.rule)
+			if tt.want == nil {
+				if err != ErrInvalidRecurrence {
+					t.Fatalf("ParseRRule(%q) = %+v, %v; want ErrInvalidRecurrence", tt.rule, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRRule(%q): %v", tt.rule, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRRule(%q) = %+v, want %+v", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRRuleBetween(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	utc := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		limit   int
+		want    []time.Time
+	}{
+		{
+			name:    "daily across a month end",
+			rule:    "FREQ=DAILY",
+			dtstart: utc(2023, 1, 30),
+			limit:   4,
+			want:    []time.Time{utc(2023, 1, 30), utc(2023, 1, 31), utc(2023, 2, 1), utc(2023, 2, 2)},
+		},
+		{
+			name:    "monthly on the 31st skips shorter months",
+			rule:    "FREQ=MONTHLY",
+			dtstart: utc(2024, 1, 31),
+			limit:   5,
+			want:    []time.Time{utc(2024, 1, 31), utc(2024, 3, 31), utc(2024, 5, 31), utc(2024, 7, 31), utc(2024, 8, 31)},
+		},
+		{
+			name:    "last day of every month",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1",
+			dtstart: utc(2024, 1, 31),
+			limit:   4,
+			want:    []time.Time{utc(2024, 1, 31), utc(2024, 2, 29), utc(2024, 3, 31), utc(2024, 4, 30)},
+		},
+		{
+			name:    "weekly by day across a month end",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,FR",
+			dtstart: utc(2024, 5, 31),
+			limit:   4,
+			want:    []time.Time{utc(2024, 5, 31), utc(2024, 6, 3), utc(2024, 6, 7), utc(2024, 6, 10)},
+		},
+		{
+			name:    "every other week across a year end",
+			rule:    "FREQ=WEEKLY;INTERVAL=2",
+			dtstart: utc(2023, 12, 20),
+			limit:   3,
+			want:    []time.Time{utc(2023, 12, 20), utc(2024, 1, 3), utc(2024, 1, 17)},
+		},
+		{
+			name:    "leap day only in leap years",
+			rule:    "FREQ=YEARLY",
+			dtstart: utc(2024, 2, 29),
+			limit:   2,
+			want:    []time.Time{utc(2024, 2, 29), utc(2028, 2, 29)},
+		},
+		{
+			name:    "count includes dtstart",
+			rule:    "FREQ=DAILY;COUNT=2",
+			dtstart: utc(2024, 1, 1),
+			limit:   10,
+			want:    []time.Time{utc(2024, 1, 1), utc(2024, 1, 2)},
+		},
+		{
+			name:    "until date includes the whole day",
+			rule:    "FREQ=DAILY;UNTIL=20240103",
+			dtstart: utc(2024, 1, 1),
+			limit:   10,
+			want:    []time.Time{utc(2024, 1, 1), utc(2024, 1, 2), utc(2024, 1, 3)},
+		},
+		{
+			name:    "daily keeps the wall clock when DST starts",
+			rule:    "FREQ=DAILY",
+			dtstart: time.Date(2024, 3, 9, 9, 30, 0, 0, newYork),
+			limit:   3,
+			want: []time.Time{
+				time.Date(2024, 3, 9, 14, 30, 0, 0, time.UTC),
+				time.Date(2024, 3, 10, 13, 30, 0, 0, time.UTC),
+				time.Date(2024, 3, 11, 13, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "weekly keeps the wall clock when DST ends",
+			rule:    "FREQ=WEEKLY",
+			dtstart: time.Date(2024, 10, 21, 9, 0, 0, 0, berlin),
+			limit:   2,
+			want: []time.Time{
+				time.Date(2024, 10, 21, 7, 0, 0, 0, time.UTC),
+				time.Date(2024, 10, 28, 8, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "monthly last day across DST and a month end",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1",
+			dtstart: time.Date(2024, 2, 29, 18, 0, 0, 0, newYork),
+			limit:   2,
+			want: []time.Time{
+				time.Date(2024, 2, 29, 23, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 31, 22, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRRule(%q): %v", tt.rule, err)
+			}
+
+			got := rule.Between(tt.dtstart, tt.dtstart, tt.dtstart.AddDate(10, 0, 0), tt.limit)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Between = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("occurrence %d = %v, want %v", i, got[i], tt.want[i])
+				}
+				if got[i].Location() != tt.dtstart.Location() {
+					t.Errorf("occurrence %d is in %v, want %v", i, got[i].Location(), tt.dtstart.Location())
+				}
+			}
+		})
+	}
+}
+
+func TestRRuleAfter(t *testing.T) {
+	dtstart := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		rule  string
+		after time.Time
+		want  *time.Time
+	}{
+		{
+			name:  "skips a month without the day",
+			rule:  "FREQ=MONTHLY",
+			after: dtstart,
+			want:  timePtr(time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:  "strictly after",
+			rule:  "FREQ=DAILY",
+			after: time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC),
+			want:  timePtr(time.Date(2024, 2, 2, 9, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:  "before dtstart",
+			rule:  "FREQ=DAILY",
+			after: dtstart.AddDate(0, 0, -7),
+			want:  &dtstart,
+		},
+		{
+			name:  "series ended by count",
+			rule:  "FREQ=DAILY;COUNT=3",
+			after: time.Date(2024, 2, 2, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "series ended by until",
+			rule:  "FREQ=WEEKLY;UNTIL=20240210T000000Z",
+			after: time.Date(2024, 2, 7, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "rule that never matches",
+			rule:  "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			after: dtstart,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRRule(%q): %v", tt.rule, err)
+			}
+
+			got := rule.After(dtstart, tt.after)
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("After = %v, want nil", *got)
+			case tt.want != nil && (got == nil || !got.Equal(*tt.want)):
+				t.Errorf("After = %v, want %v", got, *tt.want)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,8 @@
+package model
+
+type SearchResult struct {
+	Task                 *Task
+	Rank                 float32
+	TitleHighlight       string
+	DescriptionHighlight string
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxTagLength   = 64
+	MaxTagsPerTask = 50
+)
+
+var ErrInvalidTag = errors.New("invalid tag")
+
+type TagCount struct {
+	Tag   string
+	Count int64
+}
+
+// NormalizeTags trims and lowercases tags, so "Urgent" and "urgent " are
+// the same label, and returns them sorted without duplicates.
+func NormalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, ErrInvalidTag
+		}
+		normalized = append(normalized, tag)
+	}
+	return uniqueSorted(normalized), nil
+}
+
+// MergeTags removes and then adds normalized tags, so a tag named in both
+// ends up present.
+func MergeTags(tags, add, remove []string) []string {
+	removed := make(map[string]bool, len(remove))
+	for _, tag := range remove {
+		removed[tag] = true
+	}
+
+	merged := make([]string, 0, len(tags)+len(add))
+	for _, tag := range tags {
+		if !removed[tag] {
+			merged = append(merged, tag)
+		}
+	}
+	return uniqueSorted(append(merged, add...))
+}
+
+func uniqueSorted(tags []string) []string {
+	sort.Strings(tags)
+	unique := tags[:0]
+	for i, tag := range tags {
+		if i == 0 || tag != tags[i-1] {
+			unique = append(unique, tag)
+		}
+	}
+	return unique
+}
@@ -1,18 +1,70 @@
 package model
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var ErrInvalidClientID = errors.New("task id must be a UUIDv4 or UUIDv7")
+
 type Task struct {
-	ID          uuid.UUID
-	Title       string
-	Description string
-	Completed   bool
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID                 uuid.UUID
+	TenantID           string
+	OwnerID            string
+	Title              string
+	Description        string
+	Completed          bool
+	CompletedFromItems bool
+	Items              []*ChecklistItem
+	Version            int64
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	DeletedAt          *time.Time
+	DueAt              *time.Time
+	RemindAt           *time.Time
+	// Recurrence is an RRULE; completing the task spawns the next
+	// occurrence. Every occurrence shares SeriesID and is computed from
+	// RecurrenceStart.
+	Recurrence      string
+	SeriesID        *uuid.UUID
+	RecurrenceStart *time.Time
+	// Tags are normalized with NormalizeTags and kept sorted.
+	Tags     []string
+	Priority Priority
+	// Position orders the owner's tasks manually, ascending. It is assigned
+	// on insert and changed only by moves.
+	Position float64
+	// ListID is the task list the task is filed in, if any.
+	ListID *uuid.UUID
+	// ParentID is the task this one is a subtask of, if any.
+	ParentID *uuid.UUID
+	// CustomFields holds values of the fields defined for the task's list,
+	// by key. It is never nil once the task is stored.
+	CustomFields map[string]interface{}
+}
+
+type TaskUpdate struct {
+	Title              *string
+	Description        *string
+	Completed          *bool
+	CompletedFromItems *bool
+	DueAt              *time.Time
+	RemindAt           *time.Time
+	ClearDueAt         bool
+	ClearRemindAt      bool
+	Recurrence         *string
+	AddTags            []string
+	RemoveTags         []string
+	Priority           *Priority
+	ListID             *uuid.UUID
+	ClearListID        bool
+	ParentID           *uuid.UUID
+	ClearParentID      bool
+	// Custom fields are cleared before they are set.
+	SetCustomFields   map[string]interface{}
+	ClearCustomFields []string
 }
 
 func NewTask(title, description string) *Task {
@@ -21,20 +73,218 @@ func NewTask(title, description string) *Task {
 		Title:       title,
 		Description: description,
 		Completed:   false,
+		Tags:        []string{},
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 }
 
-func (t *Task) Update(title, description *string, completed *bool) {
-	if title != nil {
-		t.Title = *title
+// NormalizeTags normalizes the tags to add and remove in place.
+func (u *TaskUpdate) NormalizeTags() error {
+	var err error
+	if u.AddTags, err = NormalizeTags(u.AddTags); err != nil {
+		return err
+	}
+	u.RemoveTags, err = NormalizeTags(u.RemoveTags)
+	return err
+}
+
+func (t *Task) Update(u TaskUpdate) {
+	if u.Title != nil {
+		t.Title = *u.Title
+	}
+	if u.Description != nil {
+		t.Description = *u.Description
+	}
+	if u.Completed != nil {
+		t.Completed = *u.Completed
+	}
+	if u.CompletedFromItems != nil {
+		t.CompletedFromItems = *u.CompletedFromItems
+	}
+	if u.ClearDueAt {
+		t.DueAt = nil
+	} else if u.DueAt != nil {
+		t.DueAt = u.DueAt
+	}
+	if u.ClearRemindAt {
+		t.RemindAt = nil
+	} else if u.RemindAt != nil {
+		t.RemindAt = u.RemindAt
+	}
+	if u.Recurrence != nil {
+		t.SetRecurrence(*u.Recurrence, time.Now())
+	}
+	if u.Priority != nil {
+		t.Priority = *u.Priority
+	}
+	listID := t.ListID
+	if u.ClearListID {
+		t.ListID = nil
+	} else if u.ListID != nil {
+		t.ListID = u.ListID
+	}
+	// Values belong to the fields of a list, so a task moved to another
+	// list keeps only those set along with the move.
+	if !sameListID(listID, t.ListID) {
+		t.CustomFields = map[string]interface{}{}
 	}
-	if description != nil {
-		t.Description = *description
+	if len(u.ClearCustomFields) > 0 || len(u.SetCustomFields) > 0 {
+		t.CustomFields = CopyCustomFields(t.CustomFields)
+		for _, key := range u.ClearCustomFields {
+			delete(t.CustomFields, key)
+		}
+		for key, value := range u.SetCustomFields {
+			t.CustomFields[key] = value
+		}
 	}
-	if completed != nil {
-		t.Completed = *completed
+	if u.ClearParentID {
+		t.ParentID = nil
+	} else if u.ParentID != nil {
+		t.ParentID = u.ParentID
+	}
+	if len(u.AddTags) > 0 || len(u.RemoveTags) > 0 {
+		t.Tags = MergeTags(t.Tags, u.AddTags, u.RemoveTags)
+	}
+	if t.CompletedFromItems {
+		t.Completed = ItemsCompleted(t.Items)
 	}
 	t.UpdatedAt = time.Now()
 }
+
+func sameListID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// ParseClientID validates a task id chosen by the client. Only random (v4)
+// and time-ordered (v7) UUIDs are accepted so that ids stay unguessable and
+// well distributed.
+func ParseClientID(s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, ErrInvalidClientID
+	}
+	if id.Variant() != uuid.RFC4122 || (id.Version() != 4 && id.Version() != 7) {
+		return uuid.Nil, ErrInvalidClientID
+	}
+	return id, nil
+}
+
+// IsOverdue reports whether an open task is past its due date.
+func (t *Task) IsOverdue(now time.Time) bool {
+	return !t.Completed && t.DueAt != nil && t.DueAt.Before(now)
+}
+
+// ValidateRecurrence checks a rule before it is set; an empty rule is valid
+// and stops a task from recurring.
+func ValidateRecurrence(rule string) error {
+	if rule == "" {
+		return nil
+	}
+	_, err := ParseRRule(rule)
+	return err
+}
+
+// SetRecurrence anchors a new series at the task's due date, or at now if
+// it has none. The rule must have passed ValidateRecurrence.
+func (t *Task) SetRecurrence(rule string, now time.Time) {
+	if rule == "" {
+		t.Recurrence = ""
+		t.RecurrenceStart = nil
+		return
+	}
+
+	start := now
+	if t.DueAt != nil {
+		start = *t.DueAt
+	}
+	t.Recurrence = rule
+	t.RecurrenceStart = &start
+	if t.SeriesID == nil {
+		seriesID := t.ID
+		t.SeriesID = &seriesID
+	}
+}
+
+// NextOccurrence returns the task that follows t in its series, due at the
+// first occurrence after t's due date, or after now if t was completed
+// late. It returns nil once the series has ended.
+func (t *Task) NextOccurrence(now time.Time) (*Task, error) {
+	if t.Recurrence == "" || t.RecurrenceStart == nil {
+		return nil, nil
+	}
+	rule, err := ParseRRule(t.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	anchor := *t.RecurrenceStart
+	if t.DueAt != nil {
+		anchor = *t.DueAt
+	}
+	after := anchor
+	if now.After(after) {
+		after = now
+	}
+
+	dueAt := rule.After(*t.RecurrenceStart, after)
+	if dueAt == nil {
+		return nil, nil
+	}
+
+	next := NewTask(t.Title, t.Description)
+	next.CompletedFromItems = t.CompletedFromItems
+	next.DueAt = dueAt
+	if t.RemindAt != nil {
+		remindAt := dueAt.Add(t.RemindAt.Sub(anchor))
+		next.RemindAt = &remindAt
+	}
+	next.Recurrence = t.Recurrence
+	next.SeriesID = t.SeriesID
+	next.RecurrenceStart = t.RecurrenceStart
+	next.Tags = t.Tags
+	next.Priority = t.Priority
+	next.ListID = t.ListID
+	next.ParentID = t.ParentID
+	next.CustomFields = CopyCustomFields(t.CustomFields)
+
+	next.Items = make([]*ChecklistItem, len(t.Items))
+	for i, item := range t.Items {
+		next.Items[i] = NewChecklistItem(next.ID, item.Title)
+		next.Items[i].Position = i
+	}
+	return next, nil
+}
+
+// ProjectedOccurrences returns up to limit dates of the series in
+// [start, end) that follow an open task's own due date.
+func (t *Task) ProjectedOccurrences(start, end time.Time, limit int) []time.Time {
+	if t.Completed || t.Recurrence == "" || t.RecurrenceStart == nil {
+		return nil
+	}
+	rule, err := ParseRRule(t.Recurrence)
+	if err != nil {
+		return nil
+	}
+
+	from := *t.RecurrenceStart
+	if t.DueAt != nil {
+		from = *t.DueAt
+	}
+	if !start.After(from) {
+		start = from.Add(time.Nanosecond)
+	}
+	return rule.Between(*t.RecurrenceStart, start, end, limit)
+}
+
+// Occurrence is one date of a recurring series in a window. Materialized
+// occurrences are existing tasks; the others are projected from the open
+// task of the series.
+type Occurrence struct {
+	Task         *Task
+	OccursAt     time.Time
+	Materialized bool
+}
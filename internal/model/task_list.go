@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskList groups an owner's tasks into a project.
+type TaskList struct {
+	ID          uuid.UUID
+	TenantID    string
+	OwnerID     string
+	Name        string
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type TaskListUpdate struct {
+	Name        *string
+	Description *string
+}
+
+func NewTaskList(name, description string) *TaskList {
+	return &TaskList{
+		ID:          uuid.New(),
+		Name:        name,
+		Description: description,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+func (l *TaskList) Update(u TaskListUpdate) {
+	if u.Name != nil {
+		l.Name = *u.Name
+	}
+	if u.Description != nil {
+		l.Description = *u.Description
+	}
+	l.UpdatedAt = time.Now()
+}
+
+// TaskListCursor returns the keyset position after l in the listing of
+// task lists, which is ordered by creation.
+func TaskListCursor(l *TaskList) *PageCursor {
+	return &PageCursor{
+		Sort:  TaskSort{Field: SortByCreatedAt},
+		Value: l.CreatedAt.Format(time.RFC3339Nano),
+		ID:    l.ID,
+	}
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxTemplateItems    = 100
+	MaxTemplateSubtasks = 50
+)
+
+var (
+	ErrInvalidTemplate       = errors.New("invalid task template")
+	ErrInvalidTemplateID     = errors.New("invalid task template id")
+	ErrInvalidTemplateParams = errors.New("invalid task template parameters")
+)
+
+// templatePlaceholder matches {{name}}, allowing spaces inside the braces.
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([a-z][a-z0-9_]*)\s*\}\}`)
+
+// TemplateSubtask is a task nested under every task created from a
+// template.
+type TemplateSubtask struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Items       []string `json:"items"`
+}
+
+// TaskTemplate describes a task that is created over and over, together
+// with its checklist items and subtasks. Priority, Tags, ListID and
+// CustomFields are the defaults of the created task.
+type TaskTemplate struct {
+	ID           uuid.UUID
+	TenantID     string
+	OwnerID      string
+	Title        string
+	Description  string
+	Priority     Priority
+	Tags         []string
+	ListID       *uuid.UUID
+	CustomFields map[string]interface{}
+	// Items are the titles of the task's checklist items.
+	Items     []string
+	Subtasks  []TemplateSubtask
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewTaskTemplate(title, description string) *TaskTemplate {
+	return &TaskTemplate{
+		ID:           uuid.New(),
+		Title:        title,
+		Description:  description,
+		Tags:         []string{},
+		CustomFields: map[string]interface{}{},
+		Items:        []string{},
+		Subtasks:     []TemplateSubtask{},
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+}
+
+// Validate checks that every item and subtask has a title and that the
+// template stays within the limits on items and subtasks.
+func (t *TaskTemplate) Validate() error {
+	if !validTemplateItems(t.Items) || len(t.Subtasks) > MaxTemplateSubtasks {
+		return ErrInvalidTemplate
+	}
+	for _, subtask := range t.Subtasks {
+		if subtask.Title == "" || !validTemplateItems(subtask.Items) {
+			return ErrInvalidTemplate
+		}
+	}
+	return nil
+}
+
+func validTemplateItems(items []string) bool {
+	if len(items) > MaxTemplateItems {
+		return false
+	}
+	for _, item := range items {
+		if item == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Instantiate returns the task the template describes and its subtasks,
+// with the placeholders in titles and descriptions filled from params. The
+// date parameter defaults to the current date in UTC. It fails with
+// ErrInvalidTemplateParams if a placeholder has no parameter or a title
+// ends up empty.
+func (t *TaskTemplate) Instantiate(params map[string]string, now time.Time) (*Task, []*Task, error) {
+	values := map[string]string{"date": now.UTC().Format(CustomFieldDateLayout)}
+	for name, value := range params {
+		values[name] = value
+	}
+
+	missing := false
+	fill := func(s string) string {
+		return templatePlaceholder.ReplaceAllStringFunc(s, func(placeholder string) string {
+			name := templatePlaceholder.FindStringSubmatch(placeholder)[1]
+			value, ok := values[name]
+			if !ok {
+				missing = true
+			}
+			return value
+		})
+	}
+
+	task := NewTask(fill(t.Title), fill(t.Description))
+	task.Priority = t.Priority
+	task.Tags = append([]string{}, t.Tags...)
+	task.ListID = t.ListID
+	task.CustomFields = CopyCustomFields(t.CustomFields)
+	task.Items = templateItems(task.ID, t.Items, fill)
+
+	subtasks := make([]*Task, len(t.Subtasks))
+	for i, subtask := range t.Subtasks {
+		subtasks[i] = NewTask(fill(subtask.Title), fill(subtask.Description))
+		subtasks[i].ListID = t.ListID
+		subtasks[i].ParentID = &task.ID
+		subtasks[i].Items = templateItems(subtasks[i].ID, subtask.Items, fill)
+	}
+
+	if missing || !hasTitles(task) {
+		return nil, nil, ErrInvalidTemplateParams
+	}
+	for _, subtask := range subtasks {
+		if !hasTitles(subtask) {
+			return nil, nil, ErrInvalidTemplateParams
+		}
+	}
+	return task, subtasks, nil
+}
+
+func templateItems(taskID uuid.UUID, titles []string, fill func(string) string) []*ChecklistItem {
+	items := make([]*ChecklistItem, len(titles))
+	for i, title := range titles {
+		items[i] = NewChecklistItem(taskID, fill(title))
+	}
+	return items
+}
+
+// hasTitles reports whether the task and every one of its items has a
+// title.
+func hasTitles(task *Task) bool {
+	if task.Title == "" {
+		return false
+	}
+	for _, item := range task.Items {
+		if item.Title == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// TaskTemplateCursor returns the keyset position after t in the listing of
+// templates, which is ordered by creation.
+func TaskTemplateCursor(t *TaskTemplate) *PageCursor {
+	return &PageCursor{
+		Sort:  TaskSort{Field: SortByCreatedAt},
+		Value: t.CreatedAt.Format(time.RFC3339Nano),
+		ID:    t.ID,
+	}
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// MaxTreeTasks bounds the tasks GetTaskTree returns.
+const MaxTreeTasks = 1000
+
+var ErrInvalidParentID = errors.New("invalid parent task id")
+
+// TaskNode is a task together with its subtasks in manual order.
+type TaskNode struct {
+	Task     *Task
+	Children []*TaskNode
+}
+
+// TaskTree is a task and its live subtasks, transitively. A truncated tree
+// is cut off at the deepest level it reached.
+type TaskTree struct {
+	Root      *TaskNode
+	Truncated bool
+}
+
+// NewTaskTree nests tasks that are ordered so that every task follows its
+// parent, the root coming first.
+func NewTaskTree(tasks []*Task, truncated bool) *TaskTree {
+	if len(tasks) == 0 {
+		return &TaskTree{Truncated: truncated}
+	}
+
+	nodes := make(map[uuid.UUID]*TaskNode, len(tasks))
+	root := &TaskNode{Task: tasks[0]}
+	nodes[root.Task.ID] = root
+	for _, task := range tasks[1:] {
+		node := &TaskNode{Task: task}
+		nodes[task.ID] = node
+		if task.ParentID == nil {
+			continue
+		}
+		if parent, ok := nodes[*task.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	return &TaskTree{Root: root, Truncated: truncated}
+}
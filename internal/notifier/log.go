@@ -0,0 +1,28 @@
+package notifier
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+)
+
+type logNotifier struct{}
+
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Notify(ctx context.Context, reminder *model.Reminder) error {
+	slog.InfoContext(ctx, "Task reminder",
+		slog.String("task_id", reminder.Task.ID.String()),
+		slog.String("tenant_id", reminder.Task.TenantID),
+		slog.String("owner_id", reminder.Task.OwnerID),
+		slog.String("title", reminder.Task.Title),
+		slog.Time("remind_at", reminder.RemindAt))
+	return nil
+}
+
+func (n *logNotifier) Close() error {
+	return nil
+}
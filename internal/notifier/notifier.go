@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/config"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// Notifier delivers task reminders. Notify returns an error if the reminder
+// may not have been delivered, in which case it is retried on the next
+// scheduler round.
+type Notifier interface {
+	Notify(ctx context.Context, reminder *model.Reminder) error
+	Close() error
+}
+
+func New(cfg config.ReminderConfig) (Notifier, error) {
+	switch cfg.Notifier {
+	case "log":
+		return NewLogNotifier(), nil
+	case "webhook":
+		return NewWebhookNotifier(cfg.WebhookURL, cfg.WebhookTimeout)
+	default:
+		return nil, fmt.Errorf("unknown reminder notifier %q", cfg.Notifier)
+	}
+}
+
+// Event is the wire format shared by all notifiers.
+type Event struct {
+	Type     string     `json:"type"`
+	TaskID   uuid.UUID  `json:"task_id"`
+	TenantID string     `json:"tenant_id"`
+	OwnerID  string     `json:"owner_id"`
+	Title    string     `json:"title"`
+	DueAt    *time.Time `json:"due_at,omitempty"`
+	RemindAt time.Time  `json:"remind_at"`
+}
+
+func NewEvent(reminder *model.Reminder) Event {
+	return Event{
+		Type:     "task.reminder",
+		TaskID:   reminder.Task.ID,
+		TenantID: reminder.Task.TenantID,
+		OwnerID:  reminder.Task.OwnerID,
+		Title:    reminder.Task.Title,
+		DueAt:    reminder.Task.DueAt,
+		RemindAt: reminder.RemindAt,
+	}
+}
@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+)
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) (Notifier, error) {
+	if url == "" {
+		return nil, errors.New("webhook URL cannot be empty for the webhook notifier")
+	}
+
+	return &webhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Notify POSTs the event as JSON. Any 2xx response counts as delivered.
+func (n *webhookNotifier) Notify(ctx context.Context, reminder *model.Reminder) error {
+	event := NewEvent(reminder)
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", event.Type)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (n *webhookNotifier) Close() error {
+	n.client.CloseIdleConnections()
+	return nil
+}
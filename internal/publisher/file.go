@@ -0,0 +1,54 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+)
+
+// filePublisher appends one JSON envelope per line and syncs after every
+// event, so an event is only marked delivered once it is on disk.
+type filePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFilePublisher(path string) (Publisher, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &filePublisher{file: file}, nil
+}
+
+func (p *filePublisher) Publish(ctx context.Context, event *model.OutboxEvent) error {
+	line, err := json.Marshal(NewEnvelope(event))
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.file.Write(line); err != nil {
+		return err
+	}
+	return p.file.Sync()
+}
+
+func (p *filePublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.file.Close()
+}
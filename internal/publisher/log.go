@@ -0,0 +1,28 @@
+package publisher
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+)
+
+type logPublisher struct{}
+
+func NewLogPublisher() Publisher {
+	return &logPublisher{}
+}
+
+func (p *logPublisher) Publish(ctx context.Context, event *model.OutboxEvent) error {
+	slog.InfoContext(ctx, "Outbox event published",
+		slog.String("event_id", event.EventID.String()),
+		slog.String("event_type", event.Type),
+		slog.String("task_id", event.AggregateID.String()),
+		slog.String("tenant_id", event.TenantID),
+		slog.String("payload", string(event.Payload)))
+	return nil
+}
+
+func (p *logPublisher) Close() error {
+	return nil
+}
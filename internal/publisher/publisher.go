@@ -0,0 +1,56 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/config"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// Publisher delivers outbox events downstream. Publish returns an error if
+// the event may not have been delivered, in which case it is retried; a
+// consumer can see an event more than once and should deduplicate on its id.
+type Publisher interface {
+	Publish(ctx context.Context, event *model.OutboxEvent) error
+	Close() error
+}
+
+func New(cfg config.OutboxConfig) (Publisher, error) {
+	switch cfg.Publisher {
+	case "log":
+		return NewLogPublisher(), nil
+	case "webhook":
+		return NewWebhookPublisher(cfg.WebhookURL, cfg.WebhookTimeout)
+	case "file":
+		return NewFilePublisher(cfg.FilePath)
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher %q", cfg.Publisher)
+	}
+}
+
+// Envelope is the wire format shared by all publishers.
+type Envelope struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	TenantID    string          `json:"tenant_id"`
+	OwnerID     string          `json:"owner_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+func NewEnvelope(event *model.OutboxEvent) Envelope {
+	return Envelope{
+		ID:          event.EventID,
+		Type:        event.Type,
+		AggregateID: event.AggregateID,
+		TenantID:    event.TenantID,
+		OwnerID:     event.OwnerID,
+		OccurredAt:  event.CreatedAt,
+		Data:        event.Payload,
+	}
+}
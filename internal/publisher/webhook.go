@@ -0,0 +1,63 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+)
+
+type webhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookPublisher(url string, timeout time.Duration) (Publisher, error) {
+	if url == "" {
+		return nil, errors.New("webhook URL cannot be empty for the webhook publisher")
+	}
+
+	return &webhookPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Publish POSTs the envelope as JSON. Any 2xx response counts as delivered.
+func (p *webhookPublisher) Publish(ctx context.Context, event *model.OutboxEvent) error {
+	body, err := json.Marshal(NewEnvelope(event))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", event.EventID.String())
+	req.Header.Set("X-Event-Type", event.Type)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (p *webhookPublisher) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
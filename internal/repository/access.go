@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrGrantNotFound    = errors.New("task is not shared with the user")
+	ErrAssigneeNotFound = errors.New("user is not assigned to the task")
+)
+
+// AccessRepository stores who besides the owner can reach a task. It works
+// on any task of the caller's tenant; deciding whether the caller may
+// change the access of a task is up to the service.
+type AccessRepository interface {
+	// Permissions returns the caller's access to each of the given tasks
+	// that exists in the caller's tenant, deleted ones included, even when
+	// the caller has no permission on it.
+	Permissions(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID]model.Access, error)
+	Get(ctx context.Context, taskID uuid.UUID) (*model.TaskAccess, error)
+	Grant(ctx context.Context, grant model.TaskGrant) error
+	Revoke(ctx context.Context, taskID uuid.UUID, userID string) error
+	Assign(ctx context.Context, taskID uuid.UUID, userID string) error
+	Unassign(ctx context.Context, taskID uuid.UUID, userID string) error
+}
+
+type accessRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAccessRepository(db *pgxpool.Pool) AccessRepository {
+	return &accessRepository{
+		db: db,
+	}
+}
+
+func (r *accessRepository) Permissions(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID]model.Access, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("task_permissions", err)
+	}
+
+	start := time.Now()
+	q := `
+		SELECT t.id, t.owner_id,
+			CASE
+				WHEN t.owner_id = $3 THEN 'owner'
+				WHEN EXISTS (SELECT 1 FROM task_assignees a WHERE a.task_id = t.id AND a.user_id = $3) THEN 'edit'
+				ELSE COALESCE((SELECT c.permission FROM task_acl c WHERE c.task_id = t.id AND c.user_id = $3), '')
+			END
+		FROM tasks t
+		WHERE t.id = ANY($1) AND t.tenant_id = $2`
+
+	access := make(map[uuid.UUID]model.Access, len(taskIDs))
+	err = collect(ctx, txOrPool(ctx, r.db), q, []interface{}{taskIDs, caller.TenantID, caller.UserID}, func(row pgx.Rows) error {
+		var (
+			id         uuid.UUID
+			ownerID    string
+			permission string
+		)
+		if err := row.Scan(&id, &ownerID, &permission); err != nil {
+			return err
+		}
+		access[id] = model.Access{OwnerID: ownerID, Permission: model.ParsePermission(permission)}
+		return nil
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "task_permissions", q, duration, err)
+		return nil, HandlePgxError("task_permissions", err)
+	}
+
+	logSlowQuery(ctx, "task_permissions", duration)
+	return access, nil
+}
+
+// Get lists the grants in the order they were made and the assignees in
+// the order they were assigned.
+func (r *accessRepository) Get(ctx context.Context, taskID uuid.UUID) (*model.TaskAccess, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("get_task_access", err)
+	}
+
+	start := time.Now()
+	ownerQuery := `SELECT owner_id FROM tasks WHERE id = $1 AND tenant_id = $2`
+	grantsQuery := `
+		SELECT user_id, permission, created_at FROM task_acl
+		WHERE task_id = $1
+		ORDER BY created_at, user_id`
+	assigneesQuery := `
+		SELECT user_id FROM task_assignees
+		WHERE task_id = $1
+		ORDER BY assigned_at, user_id`
+
+	conn := txOrPool(ctx, r.db)
+	access := &model.TaskAccess{TaskID: taskID, Grants: []model.TaskGrant{}, AssigneeIDs: []string{}}
+
+	q := ownerQuery
+	err = conn.QueryRow(ctx, q, taskID, caller.TenantID).Scan(&access.OwnerID)
+	if err == nil {
+		q = grantsQuery
+		err = collect(ctx, conn, q, []interface{}{taskID}, func(row pgx.Rows) error {
+			grant := model.TaskGrant{TaskID: taskID}
+			var permission string
+			if err := row.Scan(&grant.UserID, &permission, &grant.CreatedAt); err != nil {
+				return err
+			}
+			grant.Permission = model.ParsePermission(permission)
+			access.Grants = append(access.Grants, grant)
+			return nil
+		})
+	}
+	if err == nil {
+		q = assigneesQuery
+		err = collect(ctx, conn, q, []interface{}{taskID}, func(row pgx.Rows) error {
+			var userID string
+			if err := row.Scan(&userID); err != nil {
+				return err
+			}
+			access.AssigneeIDs = append(access.AssigneeIDs, userID)
+			return nil
+		})
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logCriticalDBError(ctx, "get_task_access", q, duration, err)
+		}
+		return nil, HandlePgxError("get_task_access", err)
+	}
+
+	logSlowQuery(ctx, "get_task_access", duration)
+	return access, nil
+}
+
+// Grant shares the task with the user, replacing the permission of an
+// earlier grant.
+func (r *accessRepository) Grant(ctx context.Context, grant model.TaskGrant) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("grant_task_access", err)
+	}
+
+	start := time.Now()
+	q := `
+		INSERT INTO task_acl (task_id, user_id, permission, created_at)
+		SELECT id, $2, $3, $4 FROM tasks WHERE id = $1 AND tenant_id = $5
+		ON CONFLICT (task_id, user_id) DO UPDATE SET permission = EXCLUDED.permission`
+
+	tag, err := txOrPool(ctx, r.db).Exec(ctx, q,
+		grant.TaskID, grant.UserID, grant.Permission.String(), grant.CreatedAt, caller.TenantID,
+	)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "grant_task_access", q, duration, err)
+		return HandlePgxError("grant_task_access", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return WrapError("grant_task_access", ErrTaskNotFound)
+	}
+
+	logSlowQuery(ctx, "grant_task_access", duration)
+	return nil
+}
+
+func (r *accessRepository) Revoke(ctx context.Context, taskID uuid.UUID, userID string) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("revoke_task_access", err)
+	}
+
+	start := time.Now()
+	q := `
+		DELETE FROM task_acl c
+		USING tasks t
+		WHERE c.task_id = $1 AND c.user_id = $2
+			AND t.id = c.task_id AND t.tenant_id = $3`
+
+	tag, err := txOrPool(ctx, r.db).Exec(ctx, q, taskID, userID, caller.TenantID)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "revoke_task_access", q, duration, err)
+		return HandlePgxError("revoke_task_access", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return WrapError("revoke_task_access", ErrGrantNotFound)
+	}
+
+	logSlowQuery(ctx, "revoke_task_access", duration)
+	return nil
+}
+
+// Assign adds the user to the task's assignees. Assigning a user twice
+// succeeds.
+func (r *accessRepository) Assign(ctx context.Context, taskID uuid.UUID, userID string) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("assign_task", err)
+	}
+
+	start := time.Now()
+	q := `
+		INSERT INTO task_assignees (task_id, user_id)
+		SELECT id, $2 FROM tasks WHERE id = $1 AND tenant_id = $3
+		ON CONFLICT DO NOTHING`
+	existsQuery := `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND tenant_id = $2)`
+
+	conn := txOrPool(ctx, r.db)
+	tag, err := conn.Exec(ctx, q, taskID, userID, caller.TenantID)
+
+	exists := true
+	if err == nil && tag.RowsAffected() == 0 {
+		q = existsQuery
+		err = conn.QueryRow(ctx, q, taskID, caller.TenantID).Scan(&exists)
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "assign_task", q, duration, err)
+		return HandlePgxError("assign_task", err)
+	}
+	if !exists {
+		return WrapError("assign_task", ErrTaskNotFound)
+	}
+
+	logSlowQuery(ctx, "assign_task", duration)
+	return nil
+}
+
+func (r *accessRepository) Unassign(ctx context.Context, taskID uuid.UUID, userID string) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("unassign_task", err)
+	}
+
+	start := time.Now()
+	q := `
+		DELETE FROM task_assignees a
+		USING tasks t
+		WHERE a.task_id = $1 AND a.user_id = $2
+			AND t.id = a.task_id AND t.tenant_id = $3`
+
+	tag, err := txOrPool(ctx, r.db).Exec(ctx, q, taskID, userID, caller.TenantID)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "unassign_task", q, duration, err)
+		return HandlePgxError("unassign_task", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return WrapError("unassign_task", ErrAssigneeNotFound)
+	}
+
+	logSlowQuery(ctx, "unassign_task", duration)
+	return nil
+}
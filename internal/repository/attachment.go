@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrQuotaExceeded      = errors.New("attachment quota exceeded")
+)
+
+// AttachmentRepository stores the metadata of the attachments on the
+// caller's tasks. Their contents live in a blob store; removing an
+// attachment, directly or by purging its task, queues its contents for
+// deletion from there.
+type AttachmentRepository interface {
+	// Usage returns the total size of the attachments on the caller's
+	// tasks, those in the trash included.
+	Usage(ctx context.Context) (int64, error)
+	// Create fails with ErrQuotaExceeded if the attachment would take the
+	// caller's usage over quota.
+	Create(ctx context.Context, attachment *model.Attachment, quota int64) (*model.Attachment, error)
+	Get(ctx context.Context, taskID, id uuid.UUID) (*model.Attachment, error)
+	List(ctx context.Context, taskID uuid.UUID) ([]*model.Attachment, error)
+	Delete(ctx context.Context, taskID, id uuid.UUID) error
+
+	// PendingBlobDeletions returns up to limit blobs of every tenant that
+	// are waiting to be deleted, oldest first.
+	PendingBlobDeletions(ctx context.Context, limit int) ([]model.BlobDeletion, error)
+	ForgetBlobDeletions(ctx context.Context, ids []int64) error
+}
+
+type attachmentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAttachmentRepository(db *pgxpool.Pool) AttachmentRepository {
+	return &attachmentRepository{
+		db: db,
+	}
+}
+
+const attachmentColumns = `a.id, a.task_id, a.uploader_id, a.name, a.size, a.content_type, a.checksum, a.storage_key, a.created_at`
+
+// usageQuery sums the attachments on the tasks of the owner given as $1
+// and $2.
+const usageQuery = `
+	SELECT COALESCE(SUM(a.size), 0)::bigint FROM task_attachments a
+	JOIN tasks t ON t.id = a.task_id
+	WHERE t.tenant_id = $1 AND t.owner_id = $2`
+
+func scanAttachment(row pgx.Row) (*model.Attachment, error) {
+	var attachment model.Attachment
+	err := row.Scan(
+		&attachment.ID, &attachment.TaskID, &attachment.UploaderID, &attachment.Name,
+		&attachment.Size, &attachment.ContentType, &attachment.Checksum, &attachment.StorageKey,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// handleAttachmentError maps a missing row to ErrAttachmentNotFound rather
+// than ErrTaskNotFound.
+func handleAttachmentError(op string, err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return WrapError(op, ErrAttachmentNotFound)
+	}
+	return HandlePgxError(op, err)
+}
+
+func (r *attachmentRepository) Usage(ctx context.Context) (int64, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return 0, WrapError("attachment_usage", err)
+	}
+
+	start := time.Now()
+	q := usageQuery
+
+	var usage int64
+	err = txOrPool(ctx, r.db).QueryRow(ctx, q, caller.TenantID, caller.UserID).Scan(&usage)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "attachment_usage", q, duration, err)
+		return 0, HandlePgxError("attachment_usage", err)
+	}
+
+	logSlowQuery(ctx, "attachment_usage", duration)
+	return usage, nil
+}
+
+// Create serializes the attachments of one owner so that two concurrent
+// uploads cannot both fit under the quota that only one of them fits.
+func (r *attachmentRepository) Create(ctx context.Context, attachment *model.Attachment, quota int64) (*model.Attachment, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("create_attachment", err)
+	}
+
+	start := time.Now()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		logCriticalDBError(ctx, "create_attachment", "BEGIN", time.Since(start), err)
+		return nil, HandlePgxError("create_attachment", err)
+	}
+	defer tx.Rollback(ctx)
+
+	lockQuery := `SELECT pg_advisory_xact_lock(hashtext('task_attachments:' || $1 || ':' || $2))`
+	insertQuery := `
+		INSERT INTO task_attachments AS a (id, task_id, uploader_id, name, size, content_type, checksum, storage_key, created_at)
+		SELECT $3, t.id, $4, $5, $6, $7, $8, $9, $10 FROM tasks t
+		WHERE t.id = $11 AND t.tenant_id = $1 AND t.owner_id = $2 AND t.deleted_at IS NULL
+		RETURNING ` + attachmentColumns
+
+	q := lockQuery
+	_, err = tx.Exec(ctx, q, caller.TenantID, caller.UserID)
+
+	var usage int64
+	if err == nil {
+		q = usageQuery
+		err = tx.QueryRow(ctx, q, caller.TenantID, caller.UserID).Scan(&usage)
+	}
+	if err == nil && usage+attachment.Size > quota {
+		return nil, WrapError("create_attachment", ErrQuotaExceeded)
+	}
+
+	var created *model.Attachment
+	if err == nil {
+		q = insertQuery
+		created, err = scanAttachment(tx.QueryRow(ctx, q,
+			caller.TenantID, caller.UserID,
+			attachment.ID, attachment.UploaderID, attachment.Name, attachment.Size,
+			attachment.ContentType, attachment.Checksum, attachment.StorageKey, attachment.CreatedAt,
+			attachment.TaskID,
+		))
+	}
+	if err == nil {
+		q = "COMMIT"
+		err = tx.Commit(ctx)
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logCriticalDBError(ctx, "create_attachment", q, duration, err)
+		}
+		return nil, HandlePgxError("create_attachment", err)
+	}
+
+	logSlowQuery(ctx, "create_attachment", duration)
+	return created, nil
+}
+
+func (r *attachmentRepository) Get(ctx context.Context, taskID, id uuid.UUID) (*model.Attachment, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("get_attachment", err)
+	}
+
+	start := time.Now()
+	q := `
+		SELECT ` + attachmentColumns + ` FROM task_attachments a
+		WHERE a.task_id = $1 AND a.id = $4 AND ` + liveTask
+
+	attachment, err := scanAttachment(txOrPool(ctx, r.db).QueryRow(ctx, q, taskID, caller.TenantID, caller.UserID, id))
+	duration := time.Since(start)
+
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logCriticalDBError(ctx, "get_attachment", q, duration, err)
+		}
+		return nil, handleAttachmentError("get_attachment", err)
+	}
+
+	logSlowQuery(ctx, "get_attachment", duration)
+	return attachment, nil
+}
+
+// List returns the task's attachments, oldest first.
+func (r *attachmentRepository) List(ctx context.Context, taskID uuid.UUID) ([]*model.Attachment, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("list_attachments", err)
+	}
+
+	start := time.Now()
+	q := `
+		SELECT ` + attachmentColumns + ` FROM task_attachments a
+		WHERE a.task_id = $1 AND ` + liveTask + `
+		ORDER BY a.created_at, a.id`
+	existsQuery := `SELECT ` + liveTask
+
+	conn := txOrPool(ctx, r.db)
+	attachments := []*model.Attachment{}
+	err = collect(ctx, conn, q, []interface{}{taskID, caller.TenantID, caller.UserID}, func(row pgx.Rows) error {
+		attachment, err := scanAttachment(row)
+		if err != nil {
+			return err
+		}
+		attachments = append(attachments, attachment)
+		return nil
+	})
+
+	exists := true
+	if err == nil && len(attachments) == 0 {
+		q = existsQuery
+		err = conn.QueryRow(ctx, q, taskID, caller.TenantID, caller.UserID).Scan(&exists)
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "list_attachments", q, duration, err)
+		return nil, HandlePgxError("list_attachments", err)
+	}
+	if !exists {
+		return nil, WrapError("list_attachments", ErrTaskNotFound)
+	}
+
+	logSlowQuery(ctx, "list_attachments", duration)
+	return attachments, nil
+}
+
+func (r *attachmentRepository) Delete(ctx context.Context, taskID, id uuid.UUID) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("delete_attachment", err)
+	}
+
+	start := time.Now()
+	q := `DELETE FROM task_attachments a WHERE a.task_id = $1 AND a.id = $4 AND ` + liveTask
+
+	tag, err := txOrPool(ctx, r.db).Exec(ctx, q, taskID, caller.TenantID, caller.UserID, id)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "delete_attachment", q, duration, err)
+		return HandlePgxError("delete_attachment", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return WrapError("delete_attachment", ErrAttachmentNotFound)
+	}
+
+	logSlowQuery(ctx, "delete_attachment", duration)
+	return nil
+}
+
+func (r *attachmentRepository) PendingBlobDeletions(ctx context.Context, limit int) ([]model.BlobDeletion, error) {
+	start := time.Now()
+	q := `SELECT id, storage_key FROM blob_deletions ORDER BY id LIMIT $1`
+
+	var deletions []model.BlobDeletion
+	err := collect(ctx, r.db, q, []interface{}{limit}, func(row pgx.Rows) error {
+		var deletion model.BlobDeletion
+		if err := row.Scan(&deletion.ID, &deletion.StorageKey); err != nil {
+			return err
+		}
+		deletions = append(deletions, deletion)
+		return nil
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "pending_blob_deletions", q, duration, err)
+		return nil, HandlePgxError("pending_blob_deletions", err)
+	}
+
+	logSlowQuery(ctx, "pending_blob_deletions", duration)
+	return deletions, nil
+}
+
+func (r *attachmentRepository) ForgetBlobDeletions(ctx context.Context, ids []int64) error {
+	start := time.Now()
+	q := `DELETE FROM blob_deletions WHERE id = ANY($1)`
+
+	_, err := r.db.Exec(ctx, q, ids)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "forget_blob_deletions", q, duration, err)
+		return HandlePgxError("forget_blob_deletions", err)
+	}
+
+	logSlowQuery(ctx, "forget_blob_deletions", duration)
+	return nil
+}
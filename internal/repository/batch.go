@@ -0,0 +1,436 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const patchTaskQuery = `
+	UPDATE tasks
+	SET title = COALESCE($4, title),
+		description = COALESCE($5, description),
+		completed_from_items = COALESCE($7, completed_from_items),
+		completed = CASE WHEN COALESCE($7, completed_from_items) THEN ` + derivedCompletedExpr + `
+			ELSE COALESCE($6, completed) END,
+		due_at = CASE WHEN $11 THEN NULL ELSE COALESCE($9, due_at) END,
+		remind_at = CASE WHEN $12 THEN NULL ELSE COALESCE($10, remind_at) END,
+		recurrence = COALESCE($13, recurrence),
+		series_id = CASE WHEN COALESCE($13, '') <> '' THEN COALESCE(series_id, id) ELSE series_id END,
+		recurrence_start = CASE
+			WHEN $13::text IS NULL THEN recurrence_start
+			WHEN $13 = '' THEN NULL
+			ELSE COALESCE(CASE WHEN $11 THEN NULL ELSE COALESCE($9, due_at) END, NOW())
+		END,
+		priority = COALESCE($16, priority),
+		list_id = CASE WHEN $17 THEN NULL ELSE COALESCE($18, list_id) END,
+		parent_id = CASE WHEN $19 THEN NULL ELSE COALESCE($20, parent_id) END,
+		custom_fields = CASE
+			WHEN (CASE WHEN $17 THEN NULL ELSE COALESCE($18, list_id) END) IS DISTINCT FROM list_id THEN '{}'
+			ELSE custom_fields - COALESCE($21::text[], '{}')
+		END || COALESCE($22::jsonb, '{}'),
+		tags = CASE WHEN $14::text[] IS NULL AND $15::text[] IS NULL THEN tags ELSE ARRAY(
+			SELECT tag FROM unnest(tags) AS tag WHERE tag <> ALL(COALESCE($15, '{}'))
+			UNION SELECT unnest(COALESCE($14, '{}'))
+			ORDER BY 1
+		) END
+	FROM ` + previousStateQuery + `
+	WHERE id = $1 AND tenant_id = $2 AND owner_id = $3 AND deleted_at IS NULL
+		AND ($8::bigint IS NULL OR version = $8)
+	RETURNING ` + taskColumns + `, previous_list_id, previous_parent_id, previous_completed`
+
+const softDeleteTaskQuery = `
+	UPDATE tasks SET deleted_at = NOW()
+	WHERE id = $1 AND tenant_id = $2 AND owner_id = $3 AND deleted_at IS NULL
+		AND ($4::bigint IS NULL OR version = $4)
+	RETURNING ` + taskColumns
+
+// batchPlan describes one batch operation. fast applies every item in a
+// single round trip and fails as a whole; single applies item i on its own
+// and is used to find out which items fail when fast does.
+type batchPlan struct {
+	op     string
+	event  string
+	size   int
+	fast   func(ctx context.Context, tx pgx.Tx) ([]*model.Task, error)
+	single func(ctx context.Context, tx pgx.Tx, i int) (*model.Task, error)
+}
+
+func (r *taskRepository) BatchCreate(ctx context.Context, tasks []*model.Task, atomic bool) ([]model.BatchResult, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("batch_create_tasks", err)
+	}
+
+	now := time.Now().Truncate(time.Microsecond)
+	for _, task := range tasks {
+		if task.ID == uuid.Nil {
+			task.ID = uuid.New()
+		}
+		if task.Tags == nil {
+			task.Tags = []string{}
+		}
+		task.CustomFields = model.CopyCustomFields(task.CustomFields)
+		task.TenantID = caller.TenantID
+		task.OwnerID = caller.UserID
+		task.Version = 1
+		task.CreatedAt = now
+		task.UpdatedAt = now
+		task.Items = []*model.ChecklistItem{}
+	}
+
+	insert := `
+		INSERT INTO tasks (id, tenant_id, owner_id, title, description, completed, completed_from_items, created_at, updated_at,
+			due_at, remind_at, recurrence, series_id, recurrence_start, tags, priority, list_id, parent_id, custom_fields)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+	`
+	insertArgs := func(t *model.Task) []interface{} {
+		return []interface{}{
+			t.ID, t.TenantID, t.OwnerID, t.Title, t.Description,
+			t.Completed, t.CompletedFromItems, t.CreatedAt, t.UpdatedAt, t.DueAt, t.RemindAt,
+			t.Recurrence, t.SeriesID, t.RecurrenceStart, t.Tags, t.Priority, t.ListID, t.ParentID, t.CustomFields,
+		}
+	}
+
+	return r.runBatch(ctx, atomic, batchPlan{
+		op:    "batch_create_tasks",
+		event: model.OutboxTaskCreated,
+		size:  len(tasks),
+		fast: func(ctx context.Context, tx pgx.Tx) ([]*model.Task, error) {
+			_, err := tx.CopyFrom(ctx,
+				pgx.Identifier{"tasks"},
+				[]string{"id", "tenant_id", "owner_id", "title", "description", "completed", "completed_from_items", "created_at", "updated_at",
+					"due_at", "remind_at", "recurrence", "series_id", "recurrence_start", "tags", "priority", "list_id", "parent_id", "custom_fields"},
+				pgx.CopyFromSlice(len(tasks), func(i int) ([]interface{}, error) {
+					return insertArgs(tasks[i]), nil
+				}),
+			)
+			if err != nil {
+				return nil, err
+			}
+			return tasks, loadPositions(ctx, tx, tasks)
+		},
+		single: func(ctx context.Context, tx pgx.Tx, i int) (*model.Task, error) {
+			err := tx.QueryRow(ctx, insert+" RETURNING position", insertArgs(tasks[i])...).Scan(&tasks[i].Position)
+			if err != nil {
+				return nil, err
+			}
+			return tasks[i], nil
+		},
+	})
+}
+
+func (r *taskRepository) BatchUpdate(ctx context.Context, patches []model.TaskPatch, atomic bool) ([]model.BatchResult, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("batch_update_tasks", err)
+	}
+
+	args := func(p model.TaskPatch) []interface{} {
+		return []interface{}{
+			p.ID, caller.TenantID, caller.UserID,
+			p.Update.Title, p.Update.Description, p.Update.Completed, p.Update.CompletedFromItems,
+			p.ExpectedVersion, p.Update.DueAt, p.Update.RemindAt, p.Update.ClearDueAt, p.Update.ClearRemindAt,
+			p.Update.Recurrence, p.Update.AddTags, p.Update.RemoveTags, p.Update.Priority,
+			p.Update.ClearListID, p.Update.ListID, p.Update.ClearParentID, p.Update.ParentID,
+			p.Update.ClearCustomFields, p.Update.SetCustomFields,
+		}
+	}
+	// The trees of the parents tasks left are looked up once the batch is
+	// done, as a pipelined batch leaves no room for another query.
+	var previousParentIDs []*uuid.UUID
+	completing := make(map[uuid.UUID]bool)
+	scan := func(row pgx.Row) (*model.Task, error) {
+		var previousListID, previousParentID *uuid.UUID
+		var previousCompleted bool
+		task, err := scanTaskWith(row, &previousListID, &previousParentID, &previousCompleted)
+		if err == nil {
+			deferListInvalidation(ctx, previousListID)
+			previousParentIDs = append(previousParentIDs, previousParentID)
+			completing[task.ID] = task.Completed && !previousCompleted
+		}
+		return task, err
+	}
+	// The patch is applied before it is known whether the task ends up
+	// completed from its items, in which case setting completed is refused.
+	derived := func(p model.TaskPatch, task *model.Task) error {
+		if p.Update.Completed != nil && task.CompletedFromItems {
+			return WrapError("batch_update_tasks", ErrCompletedDerived)
+		}
+		return nil
+	}
+	// complete checks the tasks the patches completed, whether directly or
+	// from their checklist items, the way completing a single task is, and
+	// creates the next occurrence of the recurring ones unless it exists.
+	complete := func(ctx context.Context, tx pgx.Tx, tasks ...*model.Task) error {
+		var completed []*model.Task
+		var ids []uuid.UUID
+		for _, task := range tasks {
+			if completing[task.ID] {
+				completed = append(completed, task)
+				ids = append(ids, task.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		blocked, err := blockedTasks(ctx, tx, ids, caller)
+		if err != nil {
+			return err
+		}
+		if len(blocked) > 0 {
+			return WrapError("batch_update_tasks", ErrTaskBlocked)
+		}
+
+		ctx = context.WithValue(ctx, txKey{}, tx)
+		for _, task := range completed {
+			if task.Recurrence == "" {
+				continue
+			}
+			current := *task
+			if current.Items, err = loadItems(ctx, tx, task.ID); err != nil {
+				return err
+			}
+			next, err := current.NextOccurrence(time.Now())
+			if err != nil {
+				return err
+			}
+			if next == nil {
+				continue
+			}
+			if _, err := r.Create(ctx, next); err != nil && !errors.Is(err, ErrOccurrenceExists) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	results, err := r.runBatch(ctx, atomic, batchPlan{
+		op:    "batch_update_tasks",
+		event: model.OutboxTaskUpdated,
+		size:  len(patches),
+		fast: func(ctx context.Context, tx pgx.Tx) ([]*model.Task, error) {
+			batch := &pgx.Batch{}
+			for _, p := range patches {
+				batch.Queue(patchTaskQuery, args(p)...)
+			}
+			tasks, err := sendTaskBatch(ctx, tx, batch, len(patches), scan)
+			for i := 0; err == nil && i < len(tasks); i++ {
+				err = derived(patches[i], tasks[i])
+			}
+			if err == nil {
+				err = complete(ctx, tx, tasks...)
+			}
+			return tasks, err
+		},
+		single: func(ctx context.Context, tx pgx.Tx, i int) (*model.Task, error) {
+			p := patches[i]
+			task, err := scan(tx.QueryRow(ctx, patchTaskQuery, args(p)...))
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, missedConditionalWrite(ctx, tx, "batch_update_tasks", p.ID, caller, p.ExpectedVersion)
+			}
+			if err == nil {
+				err = derived(p, task)
+			}
+			if err == nil {
+				err = complete(ctx, tx, task)
+			}
+			return task, err
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := deferAncestorInvalidation(ctx, r.conn(ctx), previousParentIDs...); err != nil {
+		slog.WarnContext(ctx, "Failed to look up ancestors for cache invalidation",
+			slog.String("operation", "batch_update_tasks"),
+			slog.String("error", err.Error()))
+	}
+	return results, nil
+}
+
+func (r *taskRepository) BatchDelete(ctx context.Context, deletions []model.TaskDeletion, atomic bool) ([]model.BatchResult, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("batch_delete_tasks", err)
+	}
+
+	return r.runBatch(ctx, atomic, batchPlan{
+		op:    "batch_delete_tasks",
+		event: model.OutboxTaskDeleted,
+		size:  len(deletions),
+		fast: func(ctx context.Context, tx pgx.Tx) ([]*model.Task, error) {
+			batch := &pgx.Batch{}
+			for _, d := range deletions {
+				batch.Queue(softDeleteTaskQuery, d.ID, caller.TenantID, caller.UserID, d.ExpectedVersion)
+			}
+			tasks, err := sendTaskBatch(ctx, tx, batch, len(deletions), scanTask)
+			for i := 0; err == nil && i < len(tasks); i++ {
+				err = deleteSubtasks(ctx, tx, tasks[i], deletions[i].Cascade)
+			}
+			return tasks, err
+		},
+		single: func(ctx context.Context, tx pgx.Tx, i int) (*model.Task, error) {
+			d := deletions[i]
+			task, err := scanTask(tx.QueryRow(ctx, softDeleteTaskQuery, d.ID, caller.TenantID, caller.UserID, d.ExpectedVersion))
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, missedConditionalWrite(ctx, tx, "batch_delete_tasks", d.ID, caller, d.ExpectedVersion)
+			}
+			if err == nil {
+				err = deleteSubtasks(ctx, tx, task, d.Cascade)
+			}
+			if errors.Is(err, ErrTaskHasChildren) {
+				return nil, WrapError("batch_delete_tasks", err)
+			}
+			return task, err
+		},
+	})
+}
+
+// loadPositions reads back the positions the database assigned to tasks
+// inserted without RETURNING.
+func loadPositions(ctx context.Context, tx pgx.Tx, tasks []*model.Task) error {
+	ids := make([]uuid.UUID, len(tasks))
+	byID := make(map[uuid.UUID]*model.Task, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+		byID[task.ID] = task
+	}
+
+	rows, err := tx.Query(ctx, `SELECT id, position FROM tasks WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id       uuid.UUID
+			position float64
+		)
+		if err := rows.Scan(&id, &position); err != nil {
+			return err
+		}
+		byID[id].Position = position
+	}
+	return rows.Err()
+}
+
+// sendTaskBatch runs queued statements that each return one task row and
+// fails if any of them does not.
+func sendTaskBatch(ctx context.Context, tx pgx.Tx, batch *pgx.Batch, size int, scan func(row pgx.Row) (*model.Task, error)) ([]*model.Task, error) {
+	results := tx.SendBatch(ctx, batch)
+	defer results.Close()
+
+	tasks := make([]*model.Task, size)
+	for i := range tasks {
+		task, err := scan(results.QueryRow())
+		if err != nil {
+			return nil, err
+		}
+		tasks[i] = task
+	}
+	return tasks, results.Close()
+}
+
+// runBatch applies a plan in one transaction. The fast path is tried first
+// inside a savepoint; if it fails, every item is replayed under its own
+// savepoint to attribute errors. In atomic mode any failure rolls back the
+// whole batch and the remaining items report ErrBatchAborted.
+func (r *taskRepository) runBatch(ctx context.Context, atomic bool, plan batchPlan) ([]model.BatchResult, error) {
+	start := time.Now()
+	results := make([]model.BatchResult, plan.size)
+	if plan.size == 0 {
+		return results, nil
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		r.logCriticalDBError(ctx, plan.op, "BEGIN", time.Since(start), err)
+		return nil, HandlePgxError(plan.op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	tasks, err := withSavepoint(ctx, tx, func(sp pgx.Tx) ([]*model.Task, error) {
+		return plan.fast(ctx, sp)
+	})
+	if err == nil {
+		for i, task := range tasks {
+			results[i].Task = task
+		}
+	} else {
+		failed := false
+		for i := range results {
+			task, err := withSavepoint(ctx, tx, func(sp pgx.Tx) (*model.Task, error) {
+				return plan.single(ctx, sp, i)
+			})
+			if err != nil {
+				var repoErr *RepositoryError
+				if !errors.As(err, &repoErr) {
+					err = HandlePgxError(plan.op, err)
+				}
+				results[i].Err = err
+				failed = true
+				continue
+			}
+			results[i].Task = task
+		}
+
+		if failed && atomic {
+			for i := range results {
+				if results[i].Err == nil {
+					results[i] = model.BatchResult{Err: WrapError(plan.op, ErrBatchAborted)}
+				}
+			}
+			r.logSlowQuery(ctx, plan.op, time.Since(start))
+			return results, nil
+		}
+	}
+
+	applied := make([]*model.Task, 0, len(results))
+	for _, result := range results {
+		if result.Task != nil {
+			applied = append(applied, result.Task)
+		}
+	}
+	if err := writeOutbox(ctx, tx, plan.event, applied...); err != nil {
+		r.logCriticalDBError(ctx, plan.op, insertOutboxQuery, time.Since(start), err)
+		return nil, HandlePgxError(plan.op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logCriticalDBError(ctx, plan.op, "COMMIT", time.Since(start), err)
+		return nil, HandlePgxError(plan.op, err)
+	}
+
+	r.logSlowQuery(ctx, plan.op, time.Since(start))
+	return results, nil
+}
+
+func withSavepoint[T any](ctx context.Context, tx pgx.Tx, fn func(sp pgx.Tx) (T, error)) (T, error) {
+	var zero T
+
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return zero, err
+	}
+
+	result, err := fn(sp)
+	if err != nil {
+		sp.Rollback(ctx)
+		return zero, err
+	}
+
+	if err := sp.Commit(ctx); err != nil {
+		return zero, err
+	}
+	return result, nil
+}
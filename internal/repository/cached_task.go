@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/Raisondetr3/checklist-db-service/internal/cache"
@@ -25,19 +26,27 @@ func NewCachedTaskRepository(repo TaskRepository, cache cache.RedisCache, ttl ti
 }
 
 func (r *cachedTaskRepository) Create(ctx context.Context, task *model.Task) (*model.Task, error) {
-	createdTask, err := r.repo.Create(ctx, task)
+	tracked, pending := r.tracking(ctx)
+	createdTask, err := r.repo.Create(tracked, task)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := r.cache.SetTask(ctx, createdTask, r.ttl); err != nil {
-		slog.Warn("Failed to cache created task", 
+	if r.deferInvalidation(ctx, createdTask.ID) {
+		return createdTask, nil
+	}
+
+	// Invalidate first: dropping the task's tree also drops its cached copy.
+	ids := append(pending.taskIDs(), createdTask.ID)
+	if err := r.cache.InvalidateTasks(ctx, ids, pending.listIDs()); err != nil {
+		slog.Warn("Failed to invalidate task cache", 
 			slog.String("task_id", createdTask.ID.String()),
 			slog.String("error", err.Error()))
 	}
 
-	if err := r.cache.InvalidateTaskList(ctx); err != nil {
-		slog.Warn("Failed to invalidate task list cache", 
+	if err := r.cache.SetTask(ctx, createdTask, r.ttl); err != nil {
+		slog.Warn("Failed to cache created task", 
+			slog.String("task_id", createdTask.ID.String()),
 			slog.String("error", err.Error()))
 	}
 
@@ -45,6 +54,10 @@ func (r *cachedTaskRepository) Create(ctx context.Context, task *model.Task) (*m
 }
 
 func (r *cachedTaskRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Task, error) {
+	if InTx(ctx) {
+		return r.repo.GetByID(ctx, id)
+	}
+
 	task, err := r.cache.GetTask(ctx, id)
 	if err == nil {
 		slog.Debug("Task found in cache", slog.String("task_id", id.String()))
@@ -68,66 +81,420 @@ func (r *cachedTaskRepository) GetByID(ctx context.Context, id uuid.UUID) (*mode
 	return task, nil
 }
 
-func (r *cachedTaskRepository) Update(ctx context.Context, task *model.Task) (*model.Task, error) {
-	updatedTask, err := r.repo.Update(ctx, task)
+func (r *cachedTaskRepository) Update(ctx context.Context, task *model.Task, expectedVersion *int64) (*model.Task, error) {
+	tracked, pending := r.tracking(ctx)
+	updatedTask, err := r.repo.Update(tracked, task, expectedVersion)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := r.cache.SetTask(ctx, updatedTask, r.ttl); err != nil {
-		slog.Warn("Failed to cache updated task", 
+	if r.deferInvalidation(ctx, updatedTask.ID) {
+		return updatedTask, nil
+	}
+
+	ids := append(pending.taskIDs(), updatedTask.ID)
+	if err := r.cache.InvalidateTasks(ctx, ids, pending.listIDs()); err != nil {
+		slog.Warn("Failed to invalidate task cache", 
 			slog.String("task_id", updatedTask.ID.String()),
 			slog.String("error", err.Error()))
 	}
 
-	if err := r.cache.InvalidateTaskList(ctx); err != nil {
-		slog.Warn("Failed to invalidate task list cache", 
+	if err := r.cache.SetTask(ctx, updatedTask, r.ttl); err != nil {
+		slog.Warn("Failed to cache updated task", 
+			slog.String("task_id", updatedTask.ID.String()),
 			slog.String("error", err.Error()))
 	}
 
 	return updatedTask, nil
 }
 
-func (r *cachedTaskRepository) DeleteByID(ctx context.Context, id uuid.UUID) error {
-	err := r.repo.DeleteByID(ctx, id)
+func (r *cachedTaskRepository) DeleteByID(ctx context.Context, id uuid.UUID, expectedVersion *int64, cascade bool) error {
+	tracked, pending := r.tracking(ctx)
+	err := r.repo.DeleteByID(tracked, id, expectedVersion, cascade)
 	if err != nil {
 		return err
 	}
 
-	if err := r.cache.DeleteTask(ctx, id); err != nil {
-		slog.Warn("Failed to delete task from cache", 
-			slog.String("task_id", id.String()),
+	r.invalidateTask(ctx, id, pending)
+	return nil
+}
+
+func (r *cachedTaskRepository) Restore(ctx context.Context, id uuid.UUID) (*model.Task, error) {
+	tracked, pending := r.tracking(ctx)
+	restoredTask, err := r.repo.Restore(tracked, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.deferInvalidation(ctx, restoredTask.ID) {
+		return restoredTask, nil
+	}
+
+	ids := append(pending.taskIDs(), restoredTask.ID)
+	if err := r.cache.InvalidateTasks(ctx, ids, pending.listIDs()); err != nil {
+		slog.Warn("Failed to invalidate task cache", 
+			slog.String("task_id", restoredTask.ID.String()),
 			slog.String("error", err.Error()))
 	}
 
-	if err := r.cache.InvalidateTaskList(ctx); err != nil {
-		slog.Warn("Failed to invalidate task list cache", 
+	if err := r.cache.SetTask(ctx, restoredTask, r.ttl); err != nil {
+		slog.Warn("Failed to cache restored task", 
+			slog.String("task_id", restoredTask.ID.String()),
+			slog.String("error", err.Error()))
+	}
+
+	return restoredTask, nil
+}
+
+// Move also invalidates the tasks a rebalance renumbered, which the inner
+// repository reports through the tracking context.
+func (r *cachedTaskRepository) Move(ctx context.Context, id, anchorID uuid.UUID, before bool) (*model.Task, error) {
+	tracked, pending := r.tracking(ctx)
+	task, err := r.repo.Move(tracked, id, anchorID, before)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateTask(ctx, id, pending)
+	return task, nil
+}
+
+// CompleteSubtasks also invalidates every subtask it completed, which the
+// inner repository reports through the tracking context.
+func (r *cachedTaskRepository) CompleteSubtasks(ctx context.Context, id uuid.UUID) error {
+	tracked, pending := r.tracking(ctx)
+	if err := r.repo.CompleteSubtasks(tracked, id); err != nil {
+		return err
+	}
+
+	r.invalidateTask(ctx, id, pending)
+	return nil
+}
+
+// ClearCustomField invalidates every task it changed, which the inner
+// repository reports through the tracking context.
+func (r *cachedTaskRepository) ClearCustomField(ctx context.Context, listID uuid.UUID, key string) error {
+	tracked, pending := r.tracking(ctx)
+	if err := r.repo.ClearCustomField(tracked, listID, key); err != nil {
+		return err
+	}
+
+	if r.deferInvalidation(ctx) {
+		return nil
+	}
+
+	if err := r.cache.InvalidateTasks(ctx, pending.taskIDs(), pending.listIDs()); err != nil {
+		slog.Warn("Failed to invalidate cache after clearing custom field",
+			slog.String("list_id", listID.String()),
 			slog.String("error", err.Error()))
 	}
+	return nil
+}
 
+func (r *cachedTaskRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	tracked, pending := r.tracking(ctx)
+	if err := r.repo.Purge(tracked, id); err != nil {
+		return err
+	}
+
+	r.invalidateTask(ctx, id, pending)
 	return nil
 }
 
-func (r *cachedTaskRepository) List(ctx context.Context) ([]*model.Task, error) {
-	tasks, err := r.cache.GetTaskList(ctx)
+// PurgeDeletedBefore runs without a caller identity, so there is no
+// namespace to invalidate; deleted tasks are never in the per-task cache and
+// cached listings that include them expire with the list TTL.
+func (r *cachedTaskRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time, batchSize int) (int64, error) {
+	return r.repo.PurgeDeletedBefore(ctx, cutoff, batchSize)
+}
+
+func (r *cachedTaskRepository) List(ctx context.Context, opts model.ListOptions) (*model.TaskPage, error) {
+	// Overdue depends on the current time, and the tasks assigned to the
+	// caller are written by their owners, whose writes invalidate only
+	// their own listings, so a cached page would go stale.
+	if InTx(ctx) || opts.Filter.Overdue || opts.Filter.AssignedToMe {
+		return r.repo.List(ctx, opts)
+	}
+
+	page, err := r.cache.GetTaskList(ctx, opts)
 	if err == nil {
-		slog.Debug("Task list found in cache", slog.Int("count", len(tasks)))
-		return tasks, nil
+		slog.Debug("Task list page found in cache", slog.Int("count", len(page.Tasks)))
+		return page, nil
 	}
 
-	slog.Debug("Task list not in cache, fetching from database")
+	slog.Debug("Task list page not in cache, fetching from database")
 	
-	tasks, err = r.repo.List(ctx)
+	page, err = r.repo.List(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	listTTL := 60 * time.Second 
-	if err := r.cache.SetTaskList(ctx, tasks, listTTL); err != nil {
-		slog.Warn("Failed to cache task list", 
-			slog.Int("count", len(tasks)),
+	if err := r.cache.SetTaskList(ctx, opts, page, listTTL); err != nil {
+		slog.Warn("Failed to cache task list page", 
+			slog.Int("count", len(page.Tasks)),
+			slog.String("error", err.Error()))
+	}
+
+	return page, nil
+}
+
+func (r *cachedTaskRepository) Search(ctx context.Context, query string, limit int) ([]*model.SearchResult, error) {
+	return r.repo.Search(ctx, query, limit)
+}
+
+func (r *cachedTaskRepository) ListTags(ctx context.Context) ([]*model.TagCount, error) {
+	return r.repo.ListTags(ctx)
+}
+
+func (r *cachedTaskRepository) ListRecurring(ctx context.Context, start, end time.Time, limit int) ([]*model.Task, error) {
+	return r.repo.ListRecurring(ctx, start, end, limit)
+}
+
+func (r *cachedTaskRepository) ListHistory(ctx context.Context, taskID uuid.UUID, opts model.HistoryOptions) (*model.HistoryPage, error) {
+	return r.repo.ListHistory(ctx, taskID, opts)
+}
+
+func (r *cachedTaskRepository) Tree(ctx context.Context, id uuid.UUID, limit int) (*model.TaskTree, error) {
+	if InTx(ctx) {
+		return r.repo.Tree(ctx, id, limit)
+	}
+
+	tree, err := r.cache.GetTaskTree(ctx, id, limit)
+	if err == nil {
+		slog.Debug("Task tree found in cache", slog.String("task_id", id.String()))
+		return tree, nil
+	}
+
+	tree, err = r.repo.Tree(ctx, id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.cache.SetTaskTree(ctx, id, limit, tree, r.ttl); err != nil {
+		slog.Warn("Failed to cache task tree",
+			slog.String("task_id", id.String()),
 			slog.String("error", err.Error()))
 	}
 
-	return tasks, nil
-}
\ No newline at end of file
+	return tree, nil
+}
+
+func (r *cachedTaskRepository) AddItem(ctx context.Context, item *model.ChecklistItem) (*model.Task, error) {
+	tracked, pending := r.tracking(ctx)
+	task, err := r.repo.AddItem(tracked, item)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateTask(ctx, item.TaskID, pending)
+	return task, nil
+}
+
+func (r *cachedTaskRepository) ReorderItems(ctx context.Context, taskID uuid.UUID, itemIDs []uuid.UUID) (*model.Task, error) {
+	tracked, pending := r.tracking(ctx)
+	task, err := r.repo.ReorderItems(tracked, taskID, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateTask(ctx, taskID, pending)
+	return task, nil
+}
+
+func (r *cachedTaskRepository) ToggleItem(ctx context.Context, taskID, itemID uuid.UUID, completed *bool) (*model.Task, error) {
+	tracked, pending := r.tracking(ctx)
+	task, err := r.repo.ToggleItem(tracked, taskID, itemID, completed)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateTask(ctx, taskID, pending)
+	return task, nil
+}
+
+func (r *cachedTaskRepository) DeleteItem(ctx context.Context, taskID, itemID uuid.UUID) (*model.Task, error) {
+	tracked, pending := r.tracking(ctx)
+	task, err := r.repo.DeleteItem(tracked, taskID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateTask(ctx, taskID, pending)
+	return task, nil
+}
+
+func (r *cachedTaskRepository) BatchCreate(ctx context.Context, tasks []*model.Task, atomic bool) ([]model.BatchResult, error) {
+	tracked, pending := r.tracking(ctx)
+	results, err := r.repo.BatchCreate(tracked, tasks, atomic)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateBatch(ctx, nil, pending)
+	return results, nil
+}
+
+func (r *cachedTaskRepository) BatchUpdate(ctx context.Context, patches []model.TaskPatch, atomic bool) ([]model.BatchResult, error) {
+	tracked, pending := r.tracking(ctx)
+	results, err := r.repo.BatchUpdate(tracked, patches, atomic)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateBatch(ctx, results, pending)
+	return results, nil
+}
+
+func (r *cachedTaskRepository) BatchDelete(ctx context.Context, deletions []model.TaskDeletion, atomic bool) ([]model.BatchResult, error) {
+	tracked, pending := r.tracking(ctx)
+	results, err := r.repo.BatchDelete(tracked, deletions, atomic)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateBatch(ctx, results, pending)
+	return results, nil
+}
+
+// invalidateBatch drops every task the batch changed and the listings it
+// touched in a single cache round.
+func (r *cachedTaskRepository) invalidateBatch(ctx context.Context, results []model.BatchResult, pending *pendingInvalidation) {
+	ids := make([]uuid.UUID, 0, len(results))
+	for _, result := range results {
+		if result.Task != nil {
+			ids = append(ids, result.Task.ID)
+		}
+	}
+
+	if r.deferInvalidation(ctx, ids...) {
+		return
+	}
+
+	if err := r.cache.InvalidateTasks(ctx, ids, pending.listIDs()); err != nil {
+		slog.Warn("Failed to invalidate cache after batch", 
+			slog.Int("count", len(ids)),
+			slog.String("error", err.Error()))
+	}
+}
+
+// invalidateTask drops the cached task, any task the write rewrote along
+// with it and the cached listings it touched, which embed the task's
+// completed flag and updated_at.
+func (r *cachedTaskRepository) invalidateTask(ctx context.Context, id uuid.UUID, pending *pendingInvalidation) {
+	if r.deferInvalidation(ctx, id) {
+		return
+	}
+
+	ids := append(pending.taskIDs(), id)
+	if err := r.cache.InvalidateTasks(ctx, ids, pending.listIDs()); err != nil {
+		slog.Warn("Failed to invalidate task cache", 
+			slog.String("task_id", id.String()),
+			slog.String("error", err.Error()))
+	}
+}
+
+type pendingInvalidationKey struct{}
+
+type pendingInvalidation struct {
+	mu    sync.Mutex
+	dirty bool
+	ids   []uuid.UUID
+	lists []uuid.UUID
+}
+
+func (p *pendingInvalidation) taskIDs() []uuid.UUID {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]uuid.UUID(nil), p.ids...)
+}
+
+func (p *pendingInvalidation) listIDs() []uuid.UUID {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]uuid.UUID(nil), p.lists...)
+}
+
+// tracking prepares ctx for a write of the inner repository, which reports
+// the task lists it touched and the tasks it rewrote in bulk to the
+// returned pendingInvalidation. Inside WithTx that is the transaction's
+// own, and the cached write defers to it.
+func (r *cachedTaskRepository) tracking(ctx context.Context) (context.Context, *pendingInvalidation) {
+	if pending, ok := ctx.Value(pendingInvalidationKey{}).(*pendingInvalidation); ok {
+		return ctx, pending
+	}
+
+	pending := &pendingInvalidation{}
+	return context.WithValue(ctx, pendingInvalidationKey{}, pending), pending
+}
+
+// WithTx keeps the cache out of the transaction: writes made inside it only
+// record the tasks and task lists they touched, and those are invalidated
+// once the transaction has ended, whether it committed or not.
+func (r *cachedTaskRepository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(pendingInvalidationKey{}).(*pendingInvalidation); ok {
+		return r.repo.WithTx(ctx, fn)
+	}
+
+	pending := &pendingInvalidation{}
+	err := r.repo.WithTx(context.WithValue(ctx, pendingInvalidationKey{}, pending), fn)
+
+	pending.mu.Lock()
+	dirty, ids, lists := pending.dirty, pending.ids, pending.lists
+	pending.mu.Unlock()
+
+	if dirty {
+		if cacheErr := r.cache.InvalidateTasks(ctx, ids, lists); cacheErr != nil {
+			slog.Warn("Failed to invalidate cache after transaction",
+				slog.Int("count", len(ids)),
+				slog.String("error", cacheErr.Error()))
+		}
+	}
+
+	return err
+}
+
+func (r *cachedTaskRepository) deferInvalidation(ctx context.Context, ids ...uuid.UUID) bool {
+	return deferInvalidation(ctx, ids...)
+}
+
+// deferInvalidation records ids for invalidation when ctx was prepared by
+// the cached repository. It lets the inner repository report tasks it
+// rewrote in bulk, such as on a position rebalance.
+func deferInvalidation(ctx context.Context, ids ...uuid.UUID) bool {
+	pending, ok := ctx.Value(pendingInvalidationKey{}).(*pendingInvalidation)
+	if !ok {
+		return false
+	}
+
+	pending.mu.Lock()
+	pending.dirty = true
+	pending.ids = append(pending.ids, ids...)
+	pending.mu.Unlock()
+	return true
+}
+
+// tracked reports whether ctx was prepared by the cached repository, so that
+// the inner repository can skip work that only serves invalidation.
+func tracked(ctx context.Context) bool {
+	_, ok := ctx.Value(pendingInvalidationKey{}).(*pendingInvalidation)
+	return ok
+}
+
+// deferListInvalidation records the task lists whose listings a write
+// changed when ctx was prepared by the cached repository.
+func deferListInvalidation(ctx context.Context, listIDs ...*uuid.UUID) {
+	pending, ok := ctx.Value(pendingInvalidationKey{}).(*pendingInvalidation)
+	if !ok {
+		return
+	}
+
+	pending.mu.Lock()
+	for _, listID := range listIDs {
+		if listID != nil {
+			pending.dirty = true
+			pending.lists = append(pending.lists, *listID)
+		}
+	}
+	pending.mu.Unlock()
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+const itemColumns = `id, task_id, title, completed, position, created_at, updated_at`
+
+const derivedCompletedExpr = `(SELECT COALESCE(bool_and(completed), FALSE) FROM task_items WHERE task_id = tasks.id)`
+
+func scanItem(row pgx.Row) (*model.ChecklistItem, error) {
+	var item model.ChecklistItem
+	err := row.Scan(
+		&item.ID, &item.TaskID, &item.Title, &item.Completed,
+		&item.Position, &item.CreatedAt, &item.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func loadItems(ctx context.Context, q querier, taskID uuid.UUID) ([]*model.ChecklistItem, error) {
+	rows, err := q.Query(ctx, `SELECT `+itemColumns+` FROM task_items WHERE task_id = $1 ORDER BY position, created_at`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*model.ChecklistItem{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// insertItems adds items to a task that has none yet, in the given order.
+func insertItems(ctx context.Context, tx pgx.Tx, taskID uuid.UUID, items []*model.ChecklistItem) ([]*model.ChecklistItem, error) {
+	if len(items) == 0 {
+		return []*model.ChecklistItem{}, nil
+	}
+
+	for i, item := range items {
+		item.TaskID = taskID
+		item.Position = i
+	}
+
+	_, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"task_items"},
+		[]string{"id", "task_id", "title", "completed", "position", "created_at", "updated_at"},
+		pgx.CopyFromSlice(len(items), func(i int) ([]interface{}, error) {
+			item := items[i]
+			return []interface{}{item.ID, item.TaskID, item.Title, item.Completed, item.Position, item.CreatedAt, item.UpdatedAt}, nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *taskRepository) AddItem(ctx context.Context, item *model.ChecklistItem) (*model.Task, error) {
+	return r.mutateItems(ctx, "add_checklist_item", item.TaskID, func(tx pgx.Tx) error {
+		q := `
+			INSERT INTO task_items (id, task_id, title, completed, position, created_at, updated_at)
+			VALUES ($1, $2, $3, $4,
+				(SELECT COALESCE(MAX(position), -1) + 1 FROM task_items WHERE task_id = $2),
+				$5, $6)
+		`
+		_, err := tx.Exec(ctx, q,
+			item.ID, item.TaskID, item.Title, item.Completed, item.CreatedAt, item.UpdatedAt,
+		)
+		return err
+	})
+}
+
+func (r *taskRepository) ReorderItems(ctx context.Context, taskID uuid.UUID, itemIDs []uuid.UUID) (*model.Task, error) {
+	return r.mutateItems(ctx, "reorder_checklist_items", taskID, func(tx pgx.Tx) error {
+		items, err := loadItems(ctx, tx, taskID)
+		if err != nil {
+			return err
+		}
+
+		existing := make(map[uuid.UUID]bool, len(items))
+		for _, item := range items {
+			existing[item.ID] = true
+		}
+		if len(itemIDs) != len(existing) {
+			return ErrInvalidItemOrder
+		}
+		for _, id := range itemIDs {
+			if !existing[id] {
+				return ErrInvalidItemOrder
+			}
+			delete(existing, id)
+		}
+
+		q := `
+			UPDATE task_items SET position = o.position - 1, updated_at = NOW()
+			FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, position)
+			WHERE task_items.id = o.id AND task_items.task_id = $1
+		`
+		_, err = tx.Exec(ctx, q, taskID, itemIDs)
+		return err
+	})
+}
+
+func (r *taskRepository) ToggleItem(ctx context.Context, taskID, itemID uuid.UUID, completed *bool) (*model.Task, error) {
+	return r.mutateItems(ctx, "toggle_checklist_item", taskID, func(tx pgx.Tx) error {
+		q := `
+			UPDATE task_items SET completed = COALESCE($3, NOT completed), updated_at = NOW()
+			WHERE id = $1 AND task_id = $2
+		`
+		tag, err := tx.Exec(ctx, q, itemID, taskID, completed)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrItemNotFound
+		}
+		return nil
+	})
+}
+
+func (r *taskRepository) DeleteItem(ctx context.Context, taskID, itemID uuid.UUID) (*model.Task, error) {
+	return r.mutateItems(ctx, "delete_checklist_item", taskID, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM task_items WHERE id = $1 AND task_id = $2`, itemID, taskID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrItemNotFound
+		}
+		return nil
+	})
+}
+
+// mutateItems locks the parent task, applies fn and then refreshes the
+// parent's updated_at and, for derived tasks, its completed flag, all in
+// one transaction. A change that would complete a derived task with open
+// blockers fails with ErrTaskBlocked.
+func (r *taskRepository) mutateItems(ctx context.Context, op string, taskID uuid.UUID, fn func(tx pgx.Tx) error) (*model.Task, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError(op, err)
+	}
+
+	start := time.Now()
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		r.logCriticalDBError(ctx, op, "BEGIN", time.Since(start), err)
+		return nil, HandlePgxError(op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var wasCompleted bool
+	err = tx.QueryRow(ctx,
+		`SELECT completed FROM tasks WHERE id = $1 AND tenant_id = $2 AND owner_id = $3 AND deleted_at IS NULL FOR UPDATE`,
+		taskID, caller.TenantID, caller.UserID,
+	).Scan(&wasCompleted)
+	if err != nil {
+		return nil, HandlePgxError(op, err)
+	}
+
+	if err := fn(tx); err != nil {
+		if errors.Is(err, ErrItemNotFound) || errors.Is(err, ErrInvalidItemOrder) {
+			return nil, WrapError(op, err)
+		}
+		r.logCriticalDBError(ctx, op, "", time.Since(start), err)
+		return nil, HandlePgxError(op, err)
+	}
+
+	q := `
+		UPDATE tasks SET updated_at = NOW(),
+			completed = CASE WHEN completed_from_items THEN ` + derivedCompletedExpr + ` ELSE completed END
+		WHERE id = $1
+		RETURNING ` + taskColumns
+
+	task, err := scanTask(tx.QueryRow(ctx, q, taskID))
+	if err == nil && task.Completed && !wasCompleted {
+		var blocked map[uuid.UUID]bool
+		blocked, err = blockedTasks(ctx, tx, []uuid.UUID{taskID}, caller)
+		if err == nil && blocked[taskID] {
+			return nil, WrapError(op, ErrTaskBlocked)
+		}
+	}
+	if err == nil {
+		task.Items, err = loadItems(ctx, tx, taskID)
+	}
+	if err == nil {
+		err = writeOutbox(ctx, tx, model.OutboxTaskUpdated, task)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+
+	duration := time.Since(start)
+
+	if err != nil {
+		r.logCriticalDBError(ctx, op, q, duration, err)
+		return nil, HandlePgxError(op, err)
+	}
+
+	r.logSlowQuery(ctx, op, duration)
+	return task, nil
+}
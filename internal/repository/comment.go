@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrCommentNotFound = errors.New("comment not found")
+
+// CommentRepository stores the comments of the caller's live tasks. Who may
+// change a comment is up to the service.
+type CommentRepository interface {
+	Create(ctx context.Context, comment *model.Comment) (*model.Comment, error)
+	Get(ctx context.Context, taskID, id uuid.UUID) (*model.Comment, error)
+	Update(ctx context.Context, comment *model.Comment) (*model.Comment, error)
+	Delete(ctx context.Context, taskID, id uuid.UUID) error
+	List(ctx context.Context, taskID uuid.UUID, limit int, cursor *model.PageCursor) ([]*model.Comment, *model.PageCursor, error)
+	Count(ctx context.Context, taskID uuid.UUID) (int64, error)
+}
+
+type commentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCommentRepository(db *pgxpool.Pool) CommentRepository {
+	return &commentRepository{
+		db: db,
+	}
+}
+
+const commentColumns = `c.id, c.task_id, c.author_id, c.body, c.created_at, c.edited_at`
+
+// liveTask restricts a query to the rows that belong to a live task of the
+// caller, given as $1, $2 and $3.
+const liveTask = `
+	EXISTS (
+		SELECT 1 FROM tasks t
+		WHERE t.id = $1 AND t.tenant_id = $2 AND t.owner_id = $3 AND t.deleted_at IS NULL
+	)`
+
+func scanComment(row pgx.Row) (*model.Comment, error) {
+	var comment model.Comment
+	err := row.Scan(
+		&comment.ID, &comment.TaskID, &comment.AuthorID, &comment.Body,
+		&comment.CreatedAt, &comment.EditedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// handleCommentError maps a missing row to ErrCommentNotFound rather than
+// ErrTaskNotFound.
+func handleCommentError(op string, err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return WrapError(op, ErrCommentNotFound)
+	}
+	return HandlePgxError(op, err)
+}
+
+func (r *commentRepository) Create(ctx context.Context, comment *model.Comment) (*model.Comment, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("create_comment", err)
+	}
+
+	start := time.Now()
+	q := `
+		INSERT INTO task_comments AS c (id, task_id, author_id, body, created_at)
+		SELECT $4, t.id, $5, $6, $7 FROM tasks t
+		WHERE t.id = $1 AND t.tenant_id = $2 AND t.owner_id = $3 AND t.deleted_at IS NULL
+		RETURNING ` + commentColumns
+
+	created, err := scanComment(txOrPool(ctx, r.db).QueryRow(ctx, q,
+		comment.TaskID, caller.TenantID, caller.UserID,
+		comment.ID, comment.AuthorID, comment.Body, comment.CreatedAt,
+	))
+	duration := time.Since(start)
+
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logCriticalDBError(ctx, "create_comment", q, duration, err)
+		}
+		return nil, HandlePgxError("create_comment", err)
+	}
+
+	logSlowQuery(ctx, "create_comment", duration)
+	return created, nil
+}
+
+func (r *commentRepository) Get(ctx context.Context, taskID, id uuid.UUID) (*model.Comment, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("get_comment", err)
+	}
+
+	start := time.Now()
+	q := `
+		SELECT ` + commentColumns + ` FROM task_comments c
+		WHERE c.task_id = $1 AND c.id = $4 AND ` + liveTask
+
+	comment, err := scanComment(txOrPool(ctx, r.db).QueryRow(ctx, q, taskID, caller.TenantID, caller.UserID, id))
+	duration := time.Since(start)
+
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logCriticalDBError(ctx, "get_comment", q, duration, err)
+		}
+		return nil, handleCommentError("get_comment", err)
+	}
+
+	logSlowQuery(ctx, "get_comment", duration)
+	return comment, nil
+}
+
+// Update stores the comment's body and marks it edited.
+func (r *commentRepository) Update(ctx context.Context, comment *model.Comment) (*model.Comment, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("update_comment", err)
+	}
+
+	start := time.Now()
+	q := `
+		UPDATE task_comments c SET body = $5, edited_at = NOW()
+		WHERE c.task_id = $1 AND c.id = $4 AND ` + liveTask + `
+		RETURNING ` + commentColumns
+
+	updated, err := scanComment(txOrPool(ctx, r.db).QueryRow(ctx, q,
+		comment.TaskID, caller.TenantID, caller.UserID, comment.ID, comment.Body,
+	))
+	duration := time.Since(start)
+
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logCriticalDBError(ctx, "update_comment", q, duration, err)
+		}
+		return nil, handleCommentError("update_comment", err)
+	}
+
+	logSlowQuery(ctx, "update_comment", duration)
+	return updated, nil
+}
+
+func (r *commentRepository) Delete(ctx context.Context, taskID, id uuid.UUID) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("delete_comment", err)
+	}
+
+	start := time.Now()
+	q := `DELETE FROM task_comments c WHERE c.task_id = $1 AND c.id = $4 AND ` + liveTask
+
+	tag, err := txOrPool(ctx, r.db).Exec(ctx, q, taskID, caller.TenantID, caller.UserID, id)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "delete_comment", q, duration, err)
+		return HandlePgxError("delete_comment", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return WrapError("delete_comment", ErrCommentNotFound)
+	}
+
+	logSlowQuery(ctx, "delete_comment", duration)
+	return nil
+}
+
+// List returns up to limit of the task's comments after cursor, oldest
+// first, and the cursor of the next page if there is one. A first page
+// that comes back empty is checked against the task, so that a missing
+// task is told apart from one without comments.
+func (r *commentRepository) List(ctx context.Context, taskID uuid.UUID, limit int, cursor *model.PageCursor) ([]*model.Comment, *model.PageCursor, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, nil, WrapError("list_comments", err)
+	}
+
+	start := time.Now()
+	q := `
+		SELECT ` + commentColumns + `
+		FROM task_comments c
+		WHERE c.task_id = $1 AND ` + liveTask + `
+			AND ($4::timestamptz IS NULL OR (c.created_at, c.id) > ($4, $5))
+		ORDER BY c.created_at, c.id
+		LIMIT $6
+	`
+	existsQuery := `SELECT ` + liveTask
+
+	var after *time.Time
+	afterID := uuid.Nil
+	if cursor != nil {
+		value, err := cursor.SortValue()
+		if err != nil {
+			return nil, nil, WrapError("list_comments", err)
+		}
+		t := value.(time.Time)
+		after, afterID = &t, cursor.ID
+	}
+
+	conn := txOrPool(ctx, r.db)
+	comments := []*model.Comment{}
+	err = collect(ctx, conn, q, []interface{}{taskID, caller.TenantID, caller.UserID, after, afterID, limit + 1}, func(row pgx.Rows) error {
+		comment, err := scanComment(row)
+		if err != nil {
+			return err
+		}
+		comments = append(comments, comment)
+		return nil
+	})
+
+	exists := true
+	if err == nil && len(comments) == 0 && cursor == nil {
+		q = existsQuery
+		err = conn.QueryRow(ctx, q, taskID, caller.TenantID, caller.UserID).Scan(&exists)
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "list_comments", q, duration, err)
+		return nil, nil, HandlePgxError("list_comments", err)
+	}
+	if !exists {
+		return nil, nil, WrapError("list_comments", ErrTaskNotFound)
+	}
+
+	var next *model.PageCursor
+	if len(comments) > limit {
+		comments = comments[:limit]
+		next = model.CommentCursor(comments[limit-1])
+	}
+
+	logSlowQuery(ctx, "list_comments", duration)
+	return comments, next, nil
+}
+
+func (r *commentRepository) Count(ctx context.Context, taskID uuid.UUID) (int64, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return 0, WrapError("count_comments", err)
+	}
+
+	start := time.Now()
+	q := `SELECT COUNT(*) FROM task_comments c WHERE c.task_id = $1 AND ` + liveTask
+
+	var count int64
+	err = txOrPool(ctx, r.db).QueryRow(ctx, q, taskID, caller.TenantID, caller.UserID).Scan(&count)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "count_comments", q, duration, err)
+		return 0, HandlePgxError("count_comments", err)
+	}
+
+	logSlowQuery(ctx, "count_comments", duration)
+	return count, nil
+}
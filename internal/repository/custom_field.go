@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrCustomFieldNotFound = errors.New("custom field not found")
+	ErrCustomFieldExists   = errors.New("custom field already exists")
+	ErrTooManyCustomFields = errors.New("task list has too many custom fields")
+)
+
+// CustomFieldRepository stores the custom fields defined for the caller's
+// task lists. The values live on the tasks; deleting a field leaves them
+// to TaskRepository.ClearCustomField.
+type CustomFieldRepository interface {
+	// Create fails with ErrCustomFieldExists if the list already defines
+	// the key and with ErrTooManyCustomFields if it already defines max
+	// fields.
+	Create(ctx context.Context, field *model.CustomField, max int) (*model.CustomField, error)
+	// List returns the fields of the list, oldest first.
+	List(ctx context.Context, listID uuid.UUID) ([]*model.CustomField, error)
+	Delete(ctx context.Context, listID uuid.UUID, key string) error
+}
+
+type customFieldRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCustomFieldRepository(db *pgxpool.Pool) CustomFieldRepository {
+	return &customFieldRepository{
+		db: db,
+	}
+}
+
+const customFieldColumns = `f.id, f.list_id, f.key, f.type, f.options, f.created_at`
+
+// ownList is true when the task list given as $1 belongs to the caller
+// given as $2 and $3.
+const ownList = `EXISTS (SELECT 1 FROM task_lists l WHERE l.id = $1 AND l.tenant_id = $2 AND l.owner_id = $3)`
+
+func scanCustomField(row pgx.Row) (*model.CustomField, error) {
+	var field model.CustomField
+	err := row.Scan(&field.ID, &field.ListID, &field.Key, &field.Type, &field.Options, &field.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+// handleCustomFieldError maps a missing list to ErrListNotFound rather than
+// ErrTaskNotFound.
+func handleCustomFieldError(op string, err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return WrapError(op, ErrListNotFound)
+	}
+	return HandlePgxError(op, err)
+}
+
+// Create locks the list so that concurrent definitions cannot take it
+// past max fields together.
+func (r *customFieldRepository) Create(ctx context.Context, field *model.CustomField, max int) (*model.CustomField, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("create_custom_field", err)
+	}
+
+	start := time.Now()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		logCriticalDBError(ctx, "create_custom_field", "BEGIN", time.Since(start), err)
+		return nil, HandlePgxError("create_custom_field", err)
+	}
+	defer tx.Rollback(ctx)
+
+	lockQuery := `
+		SELECT (SELECT COUNT(*) FROM task_list_fields f WHERE f.list_id = l.id) FROM task_lists l
+		WHERE l.id = $1 AND l.tenant_id = $2 AND l.owner_id = $3
+		FOR UPDATE`
+	insertQuery := `
+		INSERT INTO task_list_fields AS f (id, list_id, key, type, options, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + customFieldColumns
+
+	q := lockQuery
+	var count int
+	err = tx.QueryRow(ctx, q, field.ListID, caller.TenantID, caller.UserID).Scan(&count)
+	if err == nil && count >= max {
+		return nil, WrapError("create_custom_field", ErrTooManyCustomFields)
+	}
+
+	var created *model.CustomField
+	if err == nil {
+		q = insertQuery
+		created, err = scanCustomField(tx.QueryRow(ctx, q,
+			field.ID, field.ListID, field.Key, field.Type, field.Options, field.CreatedAt,
+		))
+	}
+	if err == nil {
+		q = "COMMIT"
+		err = tx.Commit(ctx)
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logCriticalDBError(ctx, "create_custom_field", q, duration, err)
+		}
+		return nil, handleCustomFieldError("create_custom_field", err)
+	}
+
+	logSlowQuery(ctx, "create_custom_field", duration)
+	return created, nil
+}
+
+func (r *customFieldRepository) List(ctx context.Context, listID uuid.UUID) ([]*model.CustomField, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("list_custom_fields", err)
+	}
+
+	start := time.Now()
+	q := `
+		SELECT ` + customFieldColumns + ` FROM task_list_fields f
+		WHERE f.list_id = $1 AND ` + ownList + `
+		ORDER BY f.created_at, f.id`
+	existsQuery := `SELECT ` + ownList
+
+	conn := txOrPool(ctx, r.db)
+	fields := []*model.CustomField{}
+	err = collect(ctx, conn, q, []interface{}{listID, caller.TenantID, caller.UserID}, func(row pgx.Rows) error {
+		field, err := scanCustomField(row)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, field)
+		return nil
+	})
+
+	exists := true
+	if err == nil && len(fields) == 0 {
+		q = existsQuery
+		err = conn.QueryRow(ctx, q, listID, caller.TenantID, caller.UserID).Scan(&exists)
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "list_custom_fields", q, duration, err)
+		return nil, HandlePgxError("list_custom_fields", err)
+	}
+	if !exists {
+		return nil, WrapError("list_custom_fields", ErrListNotFound)
+	}
+
+	logSlowQuery(ctx, "list_custom_fields", duration)
+	return fields, nil
+}
+
+func (r *customFieldRepository) Delete(ctx context.Context, listID uuid.UUID, key string) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("delete_custom_field", err)
+	}
+
+	start := time.Now()
+	q := `DELETE FROM task_list_fields f WHERE f.list_id = $1 AND f.key = $4 AND ` + ownList
+
+	tag, err := txOrPool(ctx, r.db).Exec(ctx, q, listID, caller.TenantID, caller.UserID, key)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "delete_custom_field", q, duration, err)
+		return HandlePgxError("delete_custom_field", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return WrapError("delete_custom_field", ErrCustomFieldNotFound)
+	}
+
+	logSlowQuery(ctx, "delete_custom_field", duration)
+	return nil
+}
+
+// ClearCustomField removes the value of key from every task of the list,
+// those in the trash included.
+func (r *taskRepository) ClearCustomField(ctx context.Context, listID uuid.UUID, key string) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("clear_custom_field", err)
+	}
+
+	start := time.Now()
+	q := `
+		UPDATE tasks SET custom_fields = custom_fields - $4::text
+		WHERE list_id = $1 AND tenant_id = $2 AND owner_id = $3 AND custom_fields ? $4
+		RETURNING ` + taskColumns
+
+	err = r.inTx(ctx, "clear_custom_field", func(ctx context.Context, tx pgx.Tx) error {
+		tasks, err := collectSubtasks(ctx, tx, q, listID, caller.TenantID, caller.UserID, key)
+		if err == nil {
+			err = writeOutbox(ctx, tx, model.OutboxTaskUpdated, tasks...)
+		}
+		if err != nil {
+			r.logCriticalDBError(ctx, "clear_custom_field", q, time.Since(start), err)
+			return HandlePgxError("clear_custom_field", err)
+		}
+		return nil
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		return err
+	}
+
+	r.logSlowQuery(ctx, "clear_custom_field", duration)
+	return nil
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrDependencyCycle    = errors.New("dependency would create a cycle")
+	ErrDependencyNotFound = errors.New("dependency not found")
+	ErrTaskBlocked        = errors.New("task has open blockers")
+)
+
+type DependencyRepository interface {
+	Add(ctx context.Context, dependency model.Dependency) error
+	Remove(ctx context.Context, dependency model.Dependency) error
+	// Blocked returns which of the given tasks have a blocker that is
+	// neither completed nor deleted. Inside a transaction the blockers stay
+	// locked until it ends, so that none of them can be reopened before a
+	// completion checked against them commits.
+	Blocked(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+	Graph(ctx context.Context, taskID uuid.UUID, limit int) (*model.TaskGraph, error)
+}
+
+type dependencyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDependencyRepository(db *pgxpool.Pool) DependencyRepository {
+	return &dependencyRepository{
+		db: db,
+	}
+}
+
+// Add records the dependency unless it would close a cycle, that is unless
+// the blocker already depends on the task, directly or not. Adds of one
+// owner are serialized so that two concurrent adds cannot close a cycle
+// between them. Adding an existing dependency succeeds.
+func (r *dependencyRepository) Add(ctx context.Context, dependency model.Dependency) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("add_dependency", err)
+	}
+
+	start := time.Now()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		logCriticalDBError(ctx, "add_dependency", "BEGIN", time.Since(start), err)
+		return HandlePgxError("add_dependency", err)
+	}
+	defer tx.Rollback(ctx)
+
+	lockQuery := `SELECT pg_advisory_xact_lock(hashtext('task_dependencies:' || $1 || ':' || $2))`
+	existsQuery := `
+		SELECT COUNT(*) FROM tasks
+		WHERE id IN ($1, $2) AND tenant_id = $3 AND owner_id = $4 AND deleted_at IS NULL`
+	cycleQuery := `
+		WITH RECURSIVE upstream (id) AS (
+			SELECT $1::uuid
+			UNION
+			SELECT d.blocker_id FROM task_dependencies d JOIN upstream u ON d.task_id = u.id
+		)
+		SELECT EXISTS (SELECT 1 FROM upstream WHERE id = $2)`
+	insertQuery := `
+		INSERT INTO task_dependencies (task_id, blocker_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+
+	q := lockQuery
+	_, err = tx.Exec(ctx, q, caller.TenantID, caller.UserID)
+
+	var found int
+	if err == nil {
+		q = existsQuery
+		err = tx.QueryRow(ctx, q, dependency.TaskID, dependency.BlockerID, caller.TenantID, caller.UserID).Scan(&found)
+	}
+	if err == nil && found < 2 {
+		return WrapError("add_dependency", ErrTaskNotFound)
+	}
+
+	var cycle bool
+	if err == nil {
+		q = cycleQuery
+		err = tx.QueryRow(ctx, q, dependency.BlockerID, dependency.TaskID).Scan(&cycle)
+	}
+	if err == nil && cycle {
+		return WrapError("add_dependency", ErrDependencyCycle)
+	}
+
+	if err == nil {
+		q = insertQuery
+		_, err = tx.Exec(ctx, q, dependency.TaskID, dependency.BlockerID)
+	}
+	if err == nil {
+		q = "COMMIT"
+		err = tx.Commit(ctx)
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "add_dependency", q, duration, err)
+		return HandlePgxError("add_dependency", err)
+	}
+
+	logSlowQuery(ctx, "add_dependency", duration)
+	return nil
+}
+
+func (r *dependencyRepository) Remove(ctx context.Context, dependency model.Dependency) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("remove_dependency", err)
+	}
+
+	start := time.Now()
+	q := `
+		DELETE FROM task_dependencies d
+		USING tasks t
+		WHERE d.task_id = $1 AND d.blocker_id = $2
+			AND t.id = d.task_id AND t.tenant_id = $3 AND t.owner_id = $4`
+
+	tag, err := txOrPool(ctx, r.db).Exec(ctx, q, dependency.TaskID, dependency.BlockerID, caller.TenantID, caller.UserID)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "remove_dependency", q, duration, err)
+		return HandlePgxError("remove_dependency", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return WrapError("remove_dependency", ErrDependencyNotFound)
+	}
+
+	logSlowQuery(ctx, "remove_dependency", duration)
+	return nil
+}
+
+func (r *dependencyRepository) Blocked(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("blocked_tasks", err)
+	}
+
+	start := time.Now()
+	blocked, err := blockedTasks(ctx, txOrPool(ctx, r.db), taskIDs, caller)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "blocked_tasks", blockedTasksQuery, duration, err)
+		return nil, HandlePgxError("blocked_tasks", err)
+	}
+
+	logSlowQuery(ctx, "blocked_tasks", duration)
+	return blocked, nil
+}
+
+// blockedTasksQuery locks every blocker rather than only the open ones: a
+// blocker that is being reopened still looks completed until that commits,
+// and locking it waits for the reopen and then sees it.
+const blockedTasksQuery = `
+	SELECT d.task_id, NOT b.completed AND b.deleted_at IS NULL
+	FROM task_dependencies d
+	JOIN tasks b ON b.id = d.blocker_id
+	WHERE d.task_id = ANY($1) AND b.tenant_id = $2 AND b.owner_id = $3
+	FOR SHARE OF b`
+
+func blockedTasks(ctx context.Context, conn querier, taskIDs []uuid.UUID, caller identity.Identity) (map[uuid.UUID]bool, error) {
+	blocked := make(map[uuid.UUID]bool)
+	err := collect(ctx, conn, blockedTasksQuery, []interface{}{taskIDs, caller.TenantID, caller.UserID}, func(row pgx.Rows) error {
+		var id uuid.UUID
+		var open bool
+		if err := row.Scan(&id, &open); err != nil {
+			return err
+		}
+		if open {
+			blocked[id] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blocked, nil
+}
+
+// Graph walks the dependencies up and down from the task. Deleted tasks and
+// their dependencies are left out, but the walk still passes through them.
+// Checklist items are not loaded.
+func (r *dependencyRepository) Graph(ctx context.Context, taskID uuid.UUID, limit int) (*model.TaskGraph, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("get_task_graph", err)
+	}
+
+	start := time.Now()
+	tasksQuery := `
+		WITH RECURSIVE upstream (id) AS (
+			SELECT $1::uuid
+			UNION
+			SELECT d.blocker_id FROM task_dependencies d JOIN upstream u ON d.task_id = u.id
+		), downstream (id) AS (
+			SELECT $1::uuid
+			UNION
+			SELECT d.task_id FROM task_dependencies d JOIN downstream w ON d.blocker_id = w.id
+		)
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE id IN (SELECT id FROM upstream UNION SELECT id FROM downstream)
+			AND tenant_id = $2 AND owner_id = $3 AND deleted_at IS NULL
+		ORDER BY id = $1 DESC, created_at, id
+		LIMIT $4`
+	edgesQuery := `
+		SELECT task_id, blocker_id FROM task_dependencies
+		WHERE task_id = ANY($1) AND blocker_id = ANY($1)
+		ORDER BY task_id, blocker_id`
+
+	conn := txOrPool(ctx, r.db)
+	q := tasksQuery
+	graph := &model.TaskGraph{Tasks: []*model.Task{}, Dependencies: []model.Dependency{}}
+	err = collect(ctx, conn, q, []interface{}{taskID, caller.TenantID, caller.UserID, limit + 1}, func(row pgx.Rows) error {
+		task, err := scanTask(row)
+		if err == nil {
+			graph.Tasks = append(graph.Tasks, task)
+		}
+		return err
+	})
+	if err == nil && (len(graph.Tasks) == 0 || graph.Tasks[0].ID != taskID) {
+		return nil, WrapError("get_task_graph", ErrTaskNotFound)
+	}
+	if err == nil && len(graph.Tasks) > limit {
+		graph.Tasks = graph.Tasks[:limit]
+		graph.Truncated = true
+	}
+
+	if err == nil {
+		ids := make([]uuid.UUID, len(graph.Tasks))
+		for i, task := range graph.Tasks {
+			ids[i] = task.ID
+		}
+		q = edgesQuery
+		err = collect(ctx, conn, q, []interface{}{ids}, func(row pgx.Rows) error {
+			var dependency model.Dependency
+			err := row.Scan(&dependency.TaskID, &dependency.BlockerID)
+			if err == nil {
+				graph.Dependencies = append(graph.Dependencies, dependency)
+			}
+			return err
+		})
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "get_task_graph", q, duration, err)
+		return nil, HandlePgxError("get_task_graph", err)
+	}
+
+	logSlowQuery(ctx, "get_task_graph", duration)
+	return graph, nil
+}
+
+func collect(ctx context.Context, conn querier, q string, args []interface{}, scan func(row pgx.Rows) error) error {
+	rows, err := conn.Query(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -12,10 +12,21 @@ import (
 var (
 	ErrTaskNotFound        = errors.New("task not found")
 	ErrTaskAlreadyExists   = errors.New("task already exists")
+	ErrOccurrenceExists    = errors.New("series already has an occurrence due then")
+	ErrVersionMismatch     = errors.New("task version mismatch")
+	ErrItemNotFound        = errors.New("checklist item not found")
+	ErrCompletedDerived    = errors.New("completed is derived from checklist items")
+	ErrBatchAborted        = errors.New("batch aborted because another item failed")
+	ErrInvalidItemOrder    = errors.New("item order must list every checklist item exactly once")
 	ErrDatabaseConnection  = errors.New("database connection error")
 	ErrInvalidData         = errors.New("invalid data provided")
 	ErrConstraintViolation = errors.New("database constraint violation")
 	ErrTransactionFailed   = errors.New("transaction failed")
+	ErrListNotFound        = errors.New("task list not found")
+	ErrListNotEmpty        = errors.New("task list still holds tasks")
+	ErrParentNotFound      = errors.New("parent task not found")
+	ErrInvalidParent       = errors.New("task cannot be nested under itself or its subtasks")
+	ErrTaskHasChildren     = errors.New("task has subtasks")
 
 	ErrHealthCheckFailed   = errors.New("health check failed")
 	ErrDatabaseUnreachable = errors.New("database unreachable")
@@ -64,10 +75,29 @@ func HandlePgxError(op string, err error) error {
 	if errors.As(err, &pgErr) {
 		switch pgErr.Code {
 		case "23505":
-			return WrapError(op, ErrTaskAlreadyExists)
+			switch pgErr.ConstraintName {
+			case "tasks_pkey":
+				return WrapError(op, ErrTaskAlreadyExists)
+			case "idx_tasks_series_due_at":
+				return WrapError(op, ErrOccurrenceExists)
+			case "task_list_fields_list_id_key_key":
+				return WrapError(op, ErrCustomFieldExists)
+			}
+			return WrapError(op, ErrConstraintViolation)
 		case "23503":
-		case "23502":
+			switch pgErr.ConstraintName {
+			case "tasks_list_id_fkey", "task_templates_list_id_fkey":
+				return WrapError(op, ErrListNotFound)
+			case "tasks_parent_id_fkey":
+				return WrapError(op, ErrParentNotFound)
+			}
+			return WrapError(op, ErrConstraintViolation)
 		case "23514":
+			if pgErr.ConstraintName == "tasks_parent_id_check" {
+				return WrapError(op, ErrInvalidParent)
+			}
+			return WrapError(op, ErrConstraintViolation)
+		case "23502":
 			return WrapError(op, ErrConstraintViolation)
 		case "08000", "08003", "08006":
 			return WrapError(op, ErrDatabaseConnection)
@@ -89,6 +119,30 @@ func IsNotFoundError(err error) bool {
 	return errors.Is(err, ErrTaskNotFound)
 }
 
+func IsListNotFoundError(err error) bool {
+	return errors.Is(err, ErrListNotFound)
+}
+
+func IsListNotEmptyError(err error) bool {
+	return errors.Is(err, ErrListNotEmpty)
+}
+
+func IsVersionMismatchError(err error) bool {
+	return errors.Is(err, ErrVersionMismatch)
+}
+
+func IsBatchAbortedError(err error) bool {
+	return errors.Is(err, ErrBatchAborted)
+}
+
+func IsItemNotFoundError(err error) bool {
+	return errors.Is(err, ErrItemNotFound)
+}
+
+func IsInvalidItemOrderError(err error) bool {
+	return errors.Is(err, ErrInvalidItemOrder)
+}
+
 func IsConstraintError(err error) bool {
 	var repoErr *RepositoryError
 	if errors.As(err, &repoErr) {
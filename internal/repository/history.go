@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// setAuditContext passes the caller and request id to the
+// record_task_history trigger for the rest of the transaction.
+func setAuditContext(ctx context.Context, tx pgx.Tx) error {
+	actorID := identity.Actor(ctx)
+	requestID, _ := ctx.Value("request_id").(string)
+
+	_, err := tx.Exec(ctx,
+		`SELECT set_config('app.actor_id', $1, true), set_config('app.request_id', $2, true)`,
+		actorID, requestID,
+	)
+	return err
+}
+
+func (r *taskRepository) ListHistory(ctx context.Context, taskID uuid.UUID, opts model.HistoryOptions) (*model.HistoryPage, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("list_task_history", err)
+	}
+
+	limit := opts.Limit()
+	var after *int64
+	if opts.Cursor != nil {
+		after = &opts.Cursor.ID
+	}
+
+	start := time.Now()
+	q := `
+		SELECT id, task_id, action, actor_id, COALESCE(request_id, ''), before, after, created_at
+		FROM task_history
+		WHERE tenant_id = $1 AND owner_id = $2 AND task_id = $3
+			AND ($4::bigint IS NULL OR id < $4)
+		ORDER BY id DESC
+		LIMIT $5
+	`
+
+	rows, err := r.conn(ctx).Query(ctx, q, caller.TenantID, caller.UserID, taskID, after, limit+1)
+	if err != nil {
+		r.logCriticalDBError(ctx, "list_task_history", q, time.Since(start), err)
+		return nil, HandlePgxError("list_task_history", err)
+	}
+	defer rows.Close()
+
+	entries := []*model.TaskHistoryEntry{}
+	for rows.Next() {
+		var (
+			entry         model.TaskHistoryEntry
+			before, after []byte
+		)
+		err := rows.Scan(
+			&entry.ID, &entry.TaskID, &entry.Action, &entry.ActorID, &entry.RequestID,
+			&before, &after, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, HandlePgxError("list_task_history", err)
+		}
+		if entry.Before, err = snapshotTask(before); err != nil {
+			return nil, WrapError("list_task_history", err)
+		}
+		if entry.After, err = snapshotTask(after); err != nil {
+			return nil, WrapError("list_task_history", err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, HandlePgxError("list_task_history", err)
+	}
+
+	page := &model.HistoryPage{Entries: entries}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.NextCursor = &model.HistoryCursor{ID: page.Entries[limit-1].ID}
+	}
+
+	r.logSlowQuery(ctx, "list_task_history", time.Since(start))
+	return page, nil
+}
+
+func snapshotTask(data []byte) (*model.Task, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var snapshot taskSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot.toTask(), nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IdempotencyRepository stores idempotency keys per caller. Reserve and
+// Complete are meant to run in the transaction of the guarded write, so a
+// key is only ever visible together with its response.
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, record model.IdempotencyRecord) (*model.IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, response []byte) error
+	DeleteExpired(ctx context.Context, batchSize int) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool) IdempotencyRepository {
+	return &idempotencyRepository{
+		db: db,
+	}
+}
+
+// Reserve claims the key, or takes over an expired one, and returns nil. If
+// the key is live it returns the stored record instead. A concurrent
+// reservation of the same key blocks until the first transaction ends.
+func (r *idempotencyRepository) Reserve(ctx context.Context, record model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("reserve_idempotency_key", err)
+	}
+
+	start := time.Now()
+	q := `
+		INSERT INTO idempotency_keys (tenant_id, owner_id, key, operation, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant_id, owner_id, key) DO UPDATE
+		SET operation = EXCLUDED.operation, request_hash = EXCLUDED.request_hash,
+			response = NULL, created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING key
+	`
+
+	db := txOrPool(ctx, r.db)
+
+	var reserved string
+	err = db.QueryRow(ctx, q,
+		caller.TenantID, caller.UserID, record.Key, record.Operation, record.RequestHash, record.ExpiresAt,
+	).Scan(&reserved)
+	if err == nil {
+		logSlowQuery(ctx, "reserve_idempotency_key", time.Since(start))
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		logCriticalDBError(ctx, "reserve_idempotency_key", q, time.Since(start), err)
+		return nil, HandlePgxError("reserve_idempotency_key", err)
+	}
+
+	existing := model.IdempotencyRecord{Key: record.Key}
+	err = db.QueryRow(ctx, `
+		SELECT operation, request_hash, response, expires_at FROM idempotency_keys
+		WHERE tenant_id = $1 AND owner_id = $2 AND key = $3
+	`, caller.TenantID, caller.UserID, record.Key).Scan(
+		&existing.Operation, &existing.RequestHash, &existing.Response, &existing.ExpiresAt,
+	)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "reserve_idempotency_key", q, duration, err)
+		return nil, HandlePgxError("reserve_idempotency_key", err)
+	}
+
+	logSlowQuery(ctx, "reserve_idempotency_key", duration)
+	return &existing, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, key string, response []byte) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("complete_idempotency_key", err)
+	}
+
+	start := time.Now()
+	q := `UPDATE idempotency_keys SET response = $4 WHERE tenant_id = $1 AND owner_id = $2 AND key = $3`
+
+	_, err = txOrPool(ctx, r.db).Exec(ctx, q, caller.TenantID, caller.UserID, key, response)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "complete_idempotency_key", q, duration, err)
+		return HandlePgxError("complete_idempotency_key", err)
+	}
+
+	logSlowQuery(ctx, "complete_idempotency_key", duration)
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, batchSize int) (int64, error) {
+	q := `
+		DELETE FROM idempotency_keys WHERE (tenant_id, owner_id, key) IN (
+			SELECT tenant_id, owner_id, key FROM idempotency_keys WHERE expires_at <= NOW() LIMIT $1
+		)
+	`
+
+	var total int64
+	for {
+		start := time.Now()
+		commandTag, err := r.db.Exec(ctx, q, batchSize)
+		duration := time.Since(start)
+
+		if err != nil {
+			logCriticalDBError(ctx, "delete_expired_idempotency_keys", q, duration, err)
+			return total, HandlePgxError("delete_expired_idempotency_keys", err)
+		}
+
+		logSlowQuery(ctx, "delete_expired_idempotency_keys", duration)
+
+		total += commandTag.RowsAffected()
+		if commandTag.RowsAffected() < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
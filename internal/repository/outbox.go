@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// OutboxRepository hands recorded domain events to a publisher. Events of
+// one task are delivered in the order they were written.
+type OutboxRepository interface {
+	ProcessPending(ctx context.Context, limit int, lease time.Duration, retry model.RetryPolicy, publish func(ctx context.Context, event *model.OutboxEvent) error) (int, error)
+	DeleteDeliveredBefore(ctx context.Context, cutoff time.Time, batchSize int) (int64, error)
+}
+
+type outboxRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepository(db *pgxpool.Pool) OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+const insertOutboxQuery = `
+	INSERT INTO outbox (event_id, aggregate_id, tenant_id, owner_id, event_type, payload)
+	VALUES ($1, $2, $3, $4, $5, $6::jsonb)
+`
+
+// writeOutbox records one event per task in tx, so the events commit or
+// roll back together with the change. Every task write passes through it,
+// so it also reports the task lists the tasks are filed in and their
+// ancestors for cache invalidation.
+func writeOutbox(ctx context.Context, tx pgx.Tx, eventType string, tasks ...*model.Task) error {
+	batch := &pgx.Batch{}
+	parentIDs := make([]*uuid.UUID, 0, len(tasks))
+	for _, task := range tasks {
+		deferListInvalidation(ctx, task.ListID)
+		parentIDs = append(parentIDs, task.ParentID)
+
+		payload, err := json.Marshal(newTaskSnapshot(task))
+		if err != nil {
+			return err
+		}
+		batch.Queue(insertOutboxQuery,
+			uuid.New(), task.ID, task.TenantID, task.OwnerID, eventType, string(payload),
+		)
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	if err := deferAncestorInvalidation(ctx, tx, parentIDs...); err != nil {
+		return err
+	}
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+// ProcessPending claims up to limit deliverable events, passes them to
+// publish one by one and records the outcome. Claiming leases the events
+// for lease and commits at once, so no transaction stays open while they
+// are published and several relays can run side by side; an event whose
+// lease runs out before its outcome is written is claimed again. An event
+// is only deliverable once every earlier event of the same task has been
+// delivered or given up on.
+func (r *outboxRepository) ProcessPending(ctx context.Context, limit int, lease time.Duration, retry model.RetryPolicy, publish func(ctx context.Context, event *model.OutboxEvent) error) (int, error) {
+	start := time.Now()
+
+	events, leasedUntil, err := r.claim(ctx, limit, lease)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	// Outcomes are written even when ctx is canceled midway, so that
+	// published events are not published again. Events never attempted are
+	// released.
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		if ctx.Err() != nil {
+			batch.Queue(`UPDATE outbox SET locked_until = NULL WHERE id = $1 AND locked_until = $2`,
+				event.ID, leasedUntil)
+			continue
+		}
+
+		event.Attempts++
+		if err := publish(ctx, event); err != nil {
+			failed := event.Attempts >= retry.MaxAttempts
+			batch.Queue(`
+				UPDATE outbox SET attempts = $3, last_error = $4, locked_until = NULL,
+					next_attempt_at = NOW() + $5::interval,
+					failed_at = CASE WHEN $6 THEN NOW() END
+				WHERE id = $1 AND locked_until = $2
+			`, event.ID, leasedUntil, event.Attempts, err.Error(), retry.Delay(event.Attempts), failed)
+		} else {
+			batch.Queue(`
+				UPDATE outbox SET attempts = $3, last_error = NULL, locked_until = NULL, delivered_at = NOW()
+				WHERE id = $1 AND locked_until = $2
+			`, event.ID, leasedUntil, event.Attempts)
+		}
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		logCriticalDBError(ctx, "process_outbox", "BEGIN", time.Since(start), err)
+		return 0, HandlePgxError("process_outbox", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		logCriticalDBError(ctx, "process_outbox", "", time.Since(start), err)
+		return 0, HandlePgxError("process_outbox", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logCriticalDBError(ctx, "process_outbox", "COMMIT", time.Since(start), err)
+		return 0, HandlePgxError("process_outbox", err)
+	}
+
+	logSlowQuery(ctx, "process_outbox", time.Since(start))
+	return len(events), nil
+}
+
+// claim leases up to limit deliverable events in id order and returns them
+// with the end of the lease, which identifies the claim when its outcome
+// is written.
+func (r *outboxRepository) claim(ctx context.Context, limit int, lease time.Duration) ([]*model.OutboxEvent, time.Time, error) {
+	start := time.Now()
+	q := `
+		UPDATE outbox SET locked_until = NOW() + $2::interval
+		WHERE id IN (
+			SELECT id FROM outbox o
+			WHERE delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= NOW()
+				AND (locked_until IS NULL OR locked_until <= NOW())
+				AND NOT EXISTS (
+					SELECT 1 FROM outbox p
+					WHERE p.aggregate_id = o.aggregate_id AND p.id < o.id
+						AND p.delivered_at IS NULL AND p.failed_at IS NULL
+				)
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_id, aggregate_id, tenant_id, owner_id, event_type, payload, attempts, created_at, locked_until
+	`
+
+	var leasedUntil time.Time
+	events := []*model.OutboxEvent{}
+	err := collect(ctx, r.db, q, []interface{}{limit, lease}, func(row pgx.Rows) error {
+		var event model.OutboxEvent
+		err := row.Scan(
+			&event.ID, &event.EventID, &event.AggregateID, &event.TenantID, &event.OwnerID,
+			&event.Type, &event.Payload, &event.Attempts, &event.CreatedAt, &leasedUntil,
+		)
+		if err != nil {
+			return err
+		}
+		events = append(events, &event)
+		return nil
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "claim_outbox", q, duration, err)
+		return nil, time.Time{}, HandlePgxError("claim_outbox", err)
+	}
+
+	logSlowQuery(ctx, "claim_outbox", duration)
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, leasedUntil, nil
+}
+
+func (r *outboxRepository) DeleteDeliveredBefore(ctx context.Context, cutoff time.Time, batchSize int) (int64, error) {
+	q := `
+		DELETE FROM outbox WHERE id IN (
+			SELECT id FROM outbox WHERE delivered_at < $1 LIMIT $2
+		)
+	`
+
+	var total int64
+	for {
+		start := time.Now()
+		commandTag, err := r.db.Exec(ctx, q, cutoff, batchSize)
+		duration := time.Since(start)
+
+		if err != nil {
+			logCriticalDBError(ctx, "trim_outbox", q, duration, err)
+			return total, HandlePgxError("trim_outbox", err)
+		}
+
+		logSlowQuery(ctx, "trim_outbox", duration)
+
+		total += commandTag.RowsAffected()
+		if commandTag.RowsAffected() < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
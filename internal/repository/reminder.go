@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReminderRepository hands due reminders to a notifier. A reminder is due
+// once remind_at has passed on an open task and has been neither sent nor
+// given up on for that remind_at.
+type ReminderRepository interface {
+	ProcessDue(ctx context.Context, limit int, lease time.Duration, retry model.RetryPolicy, notify func(ctx context.Context, reminder *model.Reminder) error) (int, error)
+}
+
+type reminderRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReminderRepository(db *pgxpool.Pool) ReminderRepository {
+	return &reminderRepository{
+		db: db,
+	}
+}
+
+// ProcessDue claims up to limit due reminders of every tenant, passes them
+// to notify one by one and records the outcome. Claiming leases the
+// reminders for lease and commits at once, so no transaction stays open
+// while they are sent and several schedulers can run side by side; a
+// reminder whose lease runs out before its outcome is written is claimed
+// again. Failed notifications are retried with backoff, so they do not
+// hold up newer reminders.
+func (r *reminderRepository) ProcessDue(ctx context.Context, limit int, lease time.Duration, retry model.RetryPolicy, notify func(ctx context.Context, reminder *model.Reminder) error) (int, error) {
+	start := time.Now()
+
+	reminders, leasedUntil, err := r.claim(ctx, limit, lease)
+	if err != nil {
+		return 0, err
+	}
+	if len(reminders) == 0 {
+		return 0, nil
+	}
+
+	// Outcomes are written even when ctx is canceled midway, so that sent
+	// reminders are not sent again. Reminders never attempted are released.
+	batch := &pgx.Batch{}
+	for _, reminder := range reminders {
+		taskID := reminder.Task.ID
+		if ctx.Err() != nil {
+			batch.Queue(`UPDATE task_reminders SET locked_until = NULL WHERE task_id = $1 AND locked_until = $2`,
+				taskID, leasedUntil)
+			continue
+		}
+
+		reminder.Attempts++
+		if err := notify(ctx, reminder); err != nil {
+			failed := reminder.Attempts >= retry.MaxAttempts
+			batch.Queue(`
+				UPDATE task_reminders SET attempts = $3, last_error = $4, locked_until = NULL,
+					next_attempt_at = NOW() + $5::interval,
+					failed_at = CASE WHEN $6 THEN NOW() END
+				WHERE task_id = $1 AND locked_until = $2
+			`, taskID, leasedUntil, reminder.Attempts, err.Error(), retry.Delay(reminder.Attempts), failed)
+		} else {
+			batch.Queue(`
+				UPDATE task_reminders SET attempts = $3, last_error = NULL, locked_until = NULL, sent_at = NOW()
+				WHERE task_id = $1 AND locked_until = $2
+			`, taskID, leasedUntil, reminder.Attempts)
+		}
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		logCriticalDBError(ctx, "process_reminders", "BEGIN", time.Since(start), err)
+		return 0, HandlePgxError("process_reminders", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		logCriticalDBError(ctx, "process_reminders", "", time.Since(start), err)
+		return 0, HandlePgxError("process_reminders", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logCriticalDBError(ctx, "process_reminders", "COMMIT", time.Since(start), err)
+		return 0, HandlePgxError("process_reminders", err)
+	}
+
+	logSlowQuery(ctx, "process_reminders", time.Since(start))
+	return len(reminders), nil
+}
+
+// claim leases up to limit due reminders, oldest first, and returns them
+// with the end of the lease, which identifies the claim when its outcome is
+// written. A reminder claimed for an earlier remind_at of the task starts
+// over. The conflict clause checks the lease again, so a reminder another
+// scheduler claimed meanwhile is left out.
+func (r *reminderRepository) claim(ctx context.Context, limit int, lease time.Duration) ([]*model.Reminder, time.Time, error) {
+	start := time.Now()
+	q := `
+		WITH due AS (
+			SELECT t.* FROM tasks t
+			LEFT JOIN task_reminders r ON r.task_id = t.id
+			WHERE t.remind_at <= NOW() AND NOT t.completed AND t.deleted_at IS NULL
+				AND (r.task_id IS NULL OR r.remind_at <> t.remind_at OR (
+					r.sent_at IS NULL AND r.failed_at IS NULL AND r.next_attempt_at <= NOW()
+						AND (r.locked_until IS NULL OR r.locked_until <= NOW())
+				))
+			ORDER BY t.remind_at
+			LIMIT $1
+		), claimed AS (
+			INSERT INTO task_reminders (task_id, remind_at, locked_until)
+			SELECT id, remind_at, NOW() + $2::interval FROM due
+			ON CONFLICT (task_id) DO UPDATE SET
+				remind_at = EXCLUDED.remind_at,
+				locked_until = EXCLUDED.locked_until,
+				attempts = CASE WHEN task_reminders.remind_at = EXCLUDED.remind_at THEN task_reminders.attempts ELSE 0 END,
+				next_attempt_at = NOW(),
+				last_error = NULL,
+				sent_at = NULL,
+				failed_at = NULL
+			WHERE task_reminders.remind_at <> EXCLUDED.remind_at OR (
+				task_reminders.sent_at IS NULL AND task_reminders.failed_at IS NULL
+					AND task_reminders.next_attempt_at <= NOW()
+					AND (task_reminders.locked_until IS NULL OR task_reminders.locked_until <= NOW())
+			)
+			RETURNING task_id, attempts, locked_until
+		)
+		SELECT ` + taskColumns + `, claimed.attempts, claimed.locked_until
+		FROM due JOIN claimed ON claimed.task_id = due.id
+		ORDER BY due.remind_at
+	`
+
+	var leasedUntil time.Time
+	reminders := []*model.Reminder{}
+	err := collect(ctx, r.db, q, []interface{}{limit, lease}, func(row pgx.Rows) error {
+		var attempts int
+		task, err := scanTaskWith(row, &attempts, &leasedUntil)
+		if err != nil {
+			return err
+		}
+		reminders = append(reminders, &model.Reminder{Task: task, RemindAt: *task.RemindAt, Attempts: attempts})
+		return nil
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "claim_reminders", q, duration, err)
+		return nil, time.Time{}, HandlePgxError("claim_reminders", err)
+	}
+
+	logSlowQuery(ctx, "claim_reminders", duration)
+	return reminders, leasedUntil, nil
+}
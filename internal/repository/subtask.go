@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// subtreeQuery selects the ids of the live subtasks of $1, transitively, as
+// task_id. A deleted subtask ends the walk below it.
+const subtreeQuery = `
+	WITH RECURSIVE subtree (task_id) AS (
+		SELECT id FROM tasks
+		WHERE parent_id = $1 AND tenant_id = $2 AND owner_id = $3 AND deleted_at IS NULL
+		UNION
+		SELECT t.id FROM tasks t JOIN subtree s ON t.parent_id = s.task_id
+		WHERE t.deleted_at IS NULL
+	)`
+
+const deleteSubtasksQuery = subtreeQuery + `
+	UPDATE tasks SET deleted_at = $4
+	FROM subtree
+	WHERE id = task_id
+	RETURNING ` + taskColumns
+
+const hasSubtasksQuery = `
+	SELECT EXISTS (
+		SELECT 1 FROM tasks
+		WHERE parent_id = $1 AND tenant_id = $2 AND owner_id = $3 AND deleted_at IS NULL
+	)`
+
+// restoreSubtasksQuery matches the subtasks deleted along with $1 by their
+// deleted_at, which a cascading delete sets to that of $1.
+const restoreSubtasksQuery = `
+	WITH RECURSIVE subtree (task_id) AS (
+		SELECT id FROM tasks
+		WHERE parent_id = $1 AND tenant_id = $2 AND owner_id = $3 AND deleted_at = $4
+		UNION
+		SELECT t.id FROM tasks t JOIN subtree s ON t.parent_id = s.task_id
+		WHERE t.deleted_at = $4
+	)
+	UPDATE tasks SET deleted_at = NULL
+	FROM subtree
+	WHERE id = task_id
+	RETURNING ` + taskColumns
+
+const detachSubtasksQuery = `
+	UPDATE tasks SET parent_id = NULL
+	WHERE parent_id = $1 AND tenant_id = $2 AND owner_id = $3
+	RETURNING ` + taskColumns
+
+const ancestorsQuery = `
+	WITH RECURSIVE ancestors (id) AS (
+		SELECT unnest($1::uuid[])
+		UNION
+		SELECT t.parent_id FROM tasks t JOIN ancestors a ON t.id = a.id
+		WHERE t.parent_id IS NOT NULL
+	)
+	SELECT id FROM ancestors`
+
+// Tree returns the task and its live subtasks level by level, each level
+// in manual order. Checklist items are not loaded.
+func (r *taskRepository) Tree(ctx context.Context, id uuid.UUID, limit int) (*model.TaskTree, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("get_task_tree", err)
+	}
+
+	start := time.Now()
+	q := `
+		WITH RECURSIVE subtree AS (
+			SELECT tasks.*, 0 AS depth FROM tasks
+			WHERE id = $1 AND tenant_id = $2 AND owner_id = $3 AND deleted_at IS NULL
+			UNION ALL
+			SELECT t.*, s.depth + 1 FROM tasks t JOIN subtree s ON t.parent_id = s.id
+			WHERE t.deleted_at IS NULL
+		)
+		SELECT ` + taskColumns + ` FROM subtree
+		ORDER BY depth, position, id
+		LIMIT $4`
+
+	tasks := []*model.Task{}
+	err = collect(ctx, r.conn(ctx), q, []interface{}{id, caller.TenantID, caller.UserID, limit + 1}, func(row pgx.Rows) error {
+		task, err := scanTask(row)
+		if err == nil {
+			tasks = append(tasks, task)
+		}
+		return err
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		r.logCriticalDBError(ctx, "get_task_tree", q, duration, err)
+		return nil, HandlePgxError("get_task_tree", err)
+	}
+	if len(tasks) == 0 {
+		return nil, WrapError("get_task_tree", ErrTaskNotFound)
+	}
+
+	r.logSlowQuery(ctx, "get_task_tree", duration)
+	if len(tasks) > limit {
+		return model.NewTaskTree(tasks[:limit], true), nil
+	}
+	return model.NewTaskTree(tasks, false), nil
+}
+
+// CompleteSubtasks completes the open subtasks of a task, transitively,
+// except recurring ones and those whose completion is derived from their
+// checklist items. It fails with ErrTaskBlocked if one of them has an open
+// blocker.
+func (r *taskRepository) CompleteSubtasks(ctx context.Context, id uuid.UUID) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("complete_subtasks", err)
+	}
+
+	start := time.Now()
+	selectQuery := subtreeQuery + `
+		SELECT id FROM tasks JOIN subtree ON id = task_id
+		WHERE NOT completed AND NOT completed_from_items AND recurrence = ''
+		FOR UPDATE OF tasks`
+	updateQuery := `
+		UPDATE tasks SET completed = TRUE
+		WHERE id = ANY($1)
+		RETURNING ` + taskColumns
+
+	err = r.inTx(ctx, "complete_subtasks", func(ctx context.Context, tx pgx.Tx) error {
+		var ids []uuid.UUID
+		err := collect(ctx, tx, selectQuery, []interface{}{id, caller.TenantID, caller.UserID}, func(row pgx.Rows) error {
+			var id uuid.UUID
+			err := row.Scan(&id)
+			if err == nil {
+				ids = append(ids, id)
+			}
+			return err
+		})
+		if err != nil {
+			r.logCriticalDBError(ctx, "complete_subtasks", selectQuery, time.Since(start), err)
+			return HandlePgxError("complete_subtasks", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		blocked, err := blockedTasks(ctx, tx, ids, caller)
+		if err != nil {
+			r.logCriticalDBError(ctx, "complete_subtasks", blockedTasksQuery, time.Since(start), err)
+			return HandlePgxError("complete_subtasks", err)
+		}
+		if len(blocked) > 0 {
+			return WrapError("complete_subtasks", ErrTaskBlocked)
+		}
+
+		tasks, err := collectSubtasks(ctx, tx, updateQuery, ids)
+		if err == nil {
+			err = writeOutbox(ctx, tx, model.OutboxTaskUpdated, tasks...)
+		}
+		if err != nil {
+			r.logCriticalDBError(ctx, "complete_subtasks", updateQuery, time.Since(start), err)
+			return HandlePgxError("complete_subtasks", err)
+		}
+		return nil
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		return err
+	}
+
+	r.logSlowQuery(ctx, "complete_subtasks", duration)
+	return nil
+}
+
+// deleteSubtasks deletes the live subtasks of a task that was just deleted
+// along with it, or fails with ErrTaskHasChildren without cascade.
+func deleteSubtasks(ctx context.Context, tx pgx.Tx, task *model.Task, cascade bool) error {
+	if !cascade {
+		var exists bool
+		err := tx.QueryRow(ctx, hasSubtasksQuery, task.ID, task.TenantID, task.OwnerID).Scan(&exists)
+		if err == nil && exists {
+			err = ErrTaskHasChildren
+		}
+		return err
+	}
+
+	tasks, err := collectSubtasks(ctx, tx, deleteSubtasksQuery, task.ID, task.TenantID, task.OwnerID, task.DeletedAt)
+	if err != nil {
+		return err
+	}
+	return writeOutbox(ctx, tx, model.OutboxTaskDeleted, tasks...)
+}
+
+// restoreSubtasks restores the subtasks that were deleted along with a task
+// that was just restored.
+func restoreSubtasks(ctx context.Context, tx pgx.Tx, task *model.Task, deletedAt time.Time) error {
+	tasks, err := collectSubtasks(ctx, tx, restoreSubtasksQuery, task.ID, task.TenantID, task.OwnerID, deletedAt)
+	if err != nil {
+		return err
+	}
+	return writeOutbox(ctx, tx, model.OutboxTaskRestored, tasks...)
+}
+
+// detachSubtasks makes the direct subtasks of a task that is about to be
+// purged top-level tasks. The foreign key would do the same, but silently.
+func detachSubtasks(ctx context.Context, tx pgx.Tx, id uuid.UUID, caller identity.Identity) error {
+	tasks, err := collectSubtasks(ctx, tx, detachSubtasksQuery, id, caller.TenantID, caller.UserID)
+	if err != nil {
+		return err
+	}
+	return writeOutbox(ctx, tx, model.OutboxTaskUpdated, tasks...)
+}
+
+// collectSubtasks runs a write that returns the subtasks it changed and
+// reports them for cache invalidation.
+func collectSubtasks(ctx context.Context, tx pgx.Tx, q string, args ...interface{}) ([]*model.Task, error) {
+	var tasks []*model.Task
+	err := collect(ctx, tx, q, args, func(row pgx.Rows) error {
+		task, err := scanTask(row)
+		if err == nil {
+			tasks = append(tasks, task)
+			deferInvalidation(ctx, task.ID)
+		}
+		return err
+	})
+	return tasks, err
+}
+
+// deferAncestorInvalidation reports the given parents and their ancestors,
+// whose cached trees embed their subtasks, when ctx was prepared by the
+// cached repository.
+func deferAncestorInvalidation(ctx context.Context, q querier, parentIDs ...*uuid.UUID) error {
+	if !tracked(ctx) {
+		return nil
+	}
+
+	var seeds []uuid.UUID
+	for _, parentID := range parentIDs {
+		if parentID != nil {
+			seeds = append(seeds, *parentID)
+		}
+	}
+	if len(seeds) == 0 {
+		return nil
+	}
+
+	var ids []uuid.UUID
+	err := collect(ctx, q, ancestorsQuery, []interface{}{seeds}, func(row pgx.Rows) error {
+		var id uuid.UUID
+		err := row.Scan(&id)
+		if err == nil {
+			ids = append(ids, id)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	deferInvalidation(ctx, ids...)
+	return nil
+}
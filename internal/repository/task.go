@@ -2,10 +2,14 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
 	"github.com/Raisondetr3/checklist-db-service/internal/model"
 	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
 	"github.com/google/uuid"
@@ -16,9 +20,31 @@ import (
 type TaskRepository interface {
 	Create(ctx context.Context, task *model.Task) (*model.Task, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Task, error)
-	Update(ctx context.Context, task *model.Task) (*model.Task, error)
-	DeleteByID(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context) ([]*model.Task, error)
+	Update(ctx context.Context, task *model.Task, expectedVersion *int64) (*model.Task, error)
+	DeleteByID(ctx context.Context, id uuid.UUID, expectedVersion *int64, cascade bool) error
+	Restore(ctx context.Context, id uuid.UUID) (*model.Task, error)
+	Purge(ctx context.Context, id uuid.UUID) error
+	Move(ctx context.Context, id, anchorID uuid.UUID, before bool) (*model.Task, error)
+	CompleteSubtasks(ctx context.Context, id uuid.UUID) error
+	ClearCustomField(ctx context.Context, listID uuid.UUID, key string) error
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time, batchSize int) (int64, error)
+
+	BatchCreate(ctx context.Context, tasks []*model.Task, atomic bool) ([]model.BatchResult, error)
+	BatchUpdate(ctx context.Context, patches []model.TaskPatch, atomic bool) ([]model.BatchResult, error)
+	BatchDelete(ctx context.Context, deletions []model.TaskDeletion, atomic bool) ([]model.BatchResult, error)
+	List(ctx context.Context, opts model.ListOptions) (*model.TaskPage, error)
+	Search(ctx context.Context, query string, limit int) ([]*model.SearchResult, error)
+	ListRecurring(ctx context.Context, start, end time.Time, limit int) ([]*model.Task, error)
+	ListTags(ctx context.Context) ([]*model.TagCount, error)
+	ListHistory(ctx context.Context, taskID uuid.UUID, opts model.HistoryOptions) (*model.HistoryPage, error)
+	Tree(ctx context.Context, id uuid.UUID, limit int) (*model.TaskTree, error)
+
+	AddItem(ctx context.Context, item *model.ChecklistItem) (*model.Task, error)
+	ReorderItems(ctx context.Context, taskID uuid.UUID, itemIDs []uuid.UUID) (*model.Task, error)
+	ToggleItem(ctx context.Context, taskID, itemID uuid.UUID, completed *bool) (*model.Task, error)
+	DeleteItem(ctx context.Context, taskID, itemID uuid.UUID) (*model.Task, error)
+
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type taskRepository struct {
@@ -31,48 +57,107 @@ func NewTaskRepository(db *pgxpool.Pool) TaskRepository {
 	}
 }
 
+const taskColumns = `id, tenant_id, owner_id, title, description, completed, completed_from_items, version, created_at, updated_at, deleted_at, due_at, remind_at, recurrence, series_id, recurrence_start, tags, priority, position, list_id, parent_id, custom_fields`
+
+// previousStateQuery locks the task being updated and exposes the list it
+// was filed in and the task it was nested under before the update as
+// previous_list_id and previous_parent_id, so that the listing of a list
+// and the tree of a parent the task leaves can be invalidated as well.
+// previous_completed tells whether the update completes the task.
+const previousStateQuery = `(
+	SELECT list_id AS previous_list_id, parent_id AS previous_parent_id, completed AS previous_completed FROM tasks
+	WHERE id = $1 AND tenant_id = $2 AND owner_id = $3
+	FOR UPDATE
+) previous`
+
+func scanTask(row pgx.Row) (*model.Task, error) {
+	return scanTaskWith(row)
+}
+
+// scanTaskWith scans taskColumns followed by the columns in extra.
+func scanTaskWith(row pgx.Row, extra ...interface{}) (*model.Task, error) {
+	var task model.Task
+	err := row.Scan(append([]interface{}{
+		&task.ID, &task.TenantID, &task.OwnerID, &task.Title, &task.Description,
+		&task.Completed, &task.CompletedFromItems, &task.Version, &task.CreatedAt, &task.UpdatedAt,
+		&task.DeletedAt, &task.DueAt, &task.RemindAt, &task.Recurrence, &task.SeriesID, &task.RecurrenceStart,
+		&task.Tags, &task.Priority, &task.Position, &task.ListID, &task.ParentID, &task.CustomFields,
+	}, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
 func (r *taskRepository) Create(ctx context.Context, task *model.Task) (*model.Task, error) {
-	task.ID = uuid.New()
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("create_task", err)
+	}
+
+	if task.ID == uuid.Nil {
+		task.ID = uuid.New()
+	}
+	if task.Tags == nil {
+		task.Tags = []string{}
+	}
+	task.CustomFields = model.CopyCustomFields(task.CustomFields)
+	task.TenantID = caller.TenantID
+	task.OwnerID = caller.UserID
 	task.CreatedAt = time.Now()
 	task.UpdatedAt = time.Now()
 
 	start := time.Now()
 	q := `
-		INSERT INTO tasks (id, title, description, completed, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, title, description, completed, created_at, updated_at
-	`
-
-	var createdTask model.Task
-	err := r.db.QueryRow(ctx, q,
-		task.ID, task.Title, task.Description, task.Completed,
-		task.CreatedAt, task.UpdatedAt,
-	).Scan(
-		&createdTask.ID, &createdTask.Title, &createdTask.Description,
-		&createdTask.Completed, &createdTask.CreatedAt, &createdTask.UpdatedAt,
-	)
+		INSERT INTO tasks (id, tenant_id, owner_id, title, description, completed, completed_from_items, created_at, updated_at,
+			due_at, remind_at, recurrence, series_id, recurrence_start, tags, priority, list_id, parent_id, custom_fields)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		RETURNING ` + taskColumns
+
+	var createdTask *model.Task
+	err = r.inTx(ctx, "create_task", func(ctx context.Context, tx pgx.Tx) error {
+		createdTask, err = scanTask(tx.QueryRow(ctx, q,
+			task.ID, task.TenantID, task.OwnerID, task.Title, task.Description, task.Completed,
+			task.CompletedFromItems, task.CreatedAt, task.UpdatedAt, task.DueAt, task.RemindAt,
+			task.Recurrence, task.SeriesID, task.RecurrenceStart, task.Tags, task.Priority, task.ListID, task.ParentID,
+			task.CustomFields,
+		))
+		if err == nil {
+			createdTask.Items, err = insertItems(ctx, tx, createdTask.ID, task.Items)
+		}
+		if err == nil {
+			err = writeOutbox(ctx, tx, model.OutboxTaskCreated, createdTask)
+		}
+		if err != nil {
+			r.logCriticalDBError(ctx, "create_task", q, time.Since(start), err)
+			return HandlePgxError("create_task", err)
+		}
+		return nil
+	})
 
 	duration := time.Since(start)
 
 	if err != nil {
-		r.logCriticalDBError(ctx, "create_task", q, duration, err)
-		return nil, HandlePgxError("create_task", err)
+		return nil, err
 	}
 
 	r.logSlowQuery(ctx, "create_task", duration)
-
-	return &createdTask, nil
+	return createdTask, nil
 }
 
 func (r *taskRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Task, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("get_task_by_id", err)
+	}
+
 	start := time.Now()
-	q := `SELECT id, title, description, completed, created_at, updated_at FROM tasks WHERE id = $1`
+	q := `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1 AND tenant_id = $2 AND owner_id = $3 AND deleted_at IS NULL`
 
-	var task model.Task
-	err := r.db.QueryRow(ctx, q, id).Scan(
-		&task.ID, &task.Title, &task.Description,
-		&task.Completed, &task.CreatedAt, &task.UpdatedAt,
-	)
+	task, err := scanTask(r.conn(ctx).QueryRow(ctx, q, id, caller.TenantID, caller.UserID))
+	if err == nil {
+		task.Items, err = loadItems(ctx, r.conn(ctx), task.ID)
+	}
 
 	duration := time.Since(start)
 
@@ -84,62 +169,543 @@ func (r *taskRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Task
 	}
 
 	r.logSlowQuery(ctx, "get_task_by_id", duration)
-	return &task, nil
+	return task, nil
 }
 
-func (r *taskRepository) Update(ctx context.Context, task *model.Task) (*model.Task, error) {
+func (r *taskRepository) Update(ctx context.Context, task *model.Task, expectedVersion *int64) (*model.Task, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("update_task", err)
+	}
+
 	start := time.Now()
 	q := `
 		UPDATE tasks 
-		SET title = $2, description = $3, completed = $4, updated_at = NOW()
-		WHERE id = $1
-		RETURNING id, title, description, completed, created_at, updated_at
-	`
+		SET title = $4, description = $5, completed_from_items = $7, updated_at = NOW(),
+			due_at = $9, remind_at = $10, recurrence = $11, series_id = $12, recurrence_start = $13,
+			tags = $14, priority = $15, list_id = $16, parent_id = $17, custom_fields = $18,
+			completed = CASE WHEN $7 THEN ` + derivedCompletedExpr + ` ELSE $6 END
+		FROM ` + previousStateQuery + `
+		WHERE id = $1 AND tenant_id = $2 AND owner_id = $3 AND deleted_at IS NULL
+			AND ($8::bigint IS NULL OR version = $8)
+		RETURNING ` + taskColumns + `, previous_list_id, previous_parent_id`
+
+	var updatedTask *model.Task
+	err = r.inTx(ctx, "update_task", func(ctx context.Context, tx pgx.Tx) error {
+		var previousListID, previousParentID *uuid.UUID
+		updatedTask, err = scanTaskWith(tx.QueryRow(ctx, q,
+			task.ID, caller.TenantID, caller.UserID,
+			task.Title, task.Description, task.Completed, task.CompletedFromItems,
+			expectedVersion, task.DueAt, task.RemindAt, task.Recurrence, task.SeriesID, task.RecurrenceStart,
+			task.Tags, task.Priority, task.ListID, task.ParentID, model.CopyCustomFields(task.CustomFields),
+		), &previousListID, &previousParentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return missedConditionalWrite(ctx, tx, "update_task", task.ID, caller, expectedVersion)
+		}
+		if err == nil {
+			deferListInvalidation(ctx, previousListID)
+			err = deferAncestorInvalidation(ctx, tx, previousParentID)
+		}
+		if err == nil {
+			updatedTask.Items, err = loadItems(ctx, tx, updatedTask.ID)
+		}
+		if err == nil {
+			err = writeOutbox(ctx, tx, model.OutboxTaskUpdated, updatedTask)
+		}
+		if err != nil {
+			r.logCriticalDBError(ctx, "update_task", q, time.Since(start), err)
+			return HandlePgxError("update_task", err)
+		}
+		return nil
+	})
+
+	duration := time.Since(start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.logSlowQuery(ctx, "update_task", duration)
+	return updatedTask, nil
+}
+
+// DeleteByID moves the task to the trash. A task with live subtasks is only
+// deleted with cascade, which deletes the whole subtree.
+func (r *taskRepository) DeleteByID(ctx context.Context, id uuid.UUID, expectedVersion *int64, cascade bool) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("delete_task", err)
+	}
+
+	start := time.Now()
+	q := softDeleteTaskQuery
+
+	err = r.inTx(ctx, "delete_task", func(ctx context.Context, tx pgx.Tx) error {
+		task, err := scanTask(tx.QueryRow(ctx, q, id, caller.TenantID, caller.UserID, expectedVersion))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return missedConditionalWrite(ctx, tx, "delete_task", id, caller, expectedVersion)
+		}
+		if err == nil {
+			q = deleteSubtasksQuery
+			err = deleteSubtasks(ctx, tx, task, cascade)
+		}
+		if err == nil {
+			err = writeOutbox(ctx, tx, model.OutboxTaskDeleted, task)
+		}
+		if errors.Is(err, ErrTaskHasChildren) {
+			return WrapError("delete_task", err)
+		}
+		if err != nil {
+			r.logCriticalDBError(ctx, "delete_task", q, time.Since(start), err)
+			return HandlePgxError("delete_task", err)
+		}
+		return nil
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		return err
+	}
+
+	r.logSlowQuery(ctx, "delete_task", duration)
+	return nil
+}
+
+// Restore brings the task back from the trash together with the subtasks
+// that were deleted along with it.
+func (r *taskRepository) Restore(ctx context.Context, id uuid.UUID) (*model.Task, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("restore_task", err)
+	}
 
-	var updatedTask model.Task
-	err := r.db.QueryRow(ctx, q, task.ID, task.Title, task.Description, task.Completed).Scan(
-		&updatedTask.ID, &updatedTask.Title, &updatedTask.Description,
-		&updatedTask.Completed, &updatedTask.CreatedAt, &updatedTask.UpdatedAt,
+	start := time.Now()
+	q := `
+		UPDATE tasks SET deleted_at = NULL
+		FROM (
+			SELECT deleted_at AS previous_deleted_at FROM tasks
+			WHERE id = $1 AND tenant_id = $2 AND owner_id = $3
+			FOR UPDATE
+		) previous
+		WHERE id = $1 AND tenant_id = $2 AND owner_id = $3 AND deleted_at IS NOT NULL
+		RETURNING ` + taskColumns + `, previous_deleted_at`
+
+	var task *model.Task
+	err = r.inTx(ctx, "restore_task", func(ctx context.Context, tx pgx.Tx) error {
+		var deletedAt time.Time
+		task, err = scanTaskWith(tx.QueryRow(ctx, q, id, caller.TenantID, caller.UserID), &deletedAt)
+		if err == nil {
+			q = restoreSubtasksQuery
+			err = restoreSubtasks(ctx, tx, task, deletedAt)
+		}
+		if err == nil {
+			task.Items, err = loadItems(ctx, tx, task.ID)
+		}
+		if err == nil {
+			err = writeOutbox(ctx, tx, model.OutboxTaskRestored, task)
+		}
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				r.logCriticalDBError(ctx, "restore_task", q, time.Since(start), err)
+			}
+			return HandlePgxError("restore_task", err)
+		}
+		return nil
+	})
+
+	duration := time.Since(start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.logSlowQuery(ctx, "restore_task", duration)
+	return task, nil
+}
+
+// Move places the task directly before or after the anchor task. The new
+// position is the midpoint between the anchor and its neighbour; once the
+// gap is too small to split, the caller's tasks are renumbered first.
+func (r *taskRepository) Move(ctx context.Context, id, anchorID uuid.UUID, before bool) (*model.Task, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("move_task", err)
+	}
+
+	start := time.Now()
+	neighbour := `(t.position, t.id) > (a.position, a.id) ORDER BY t.position, t.id`
+	if before {
+		neighbour = `(t.position, t.id) < (a.position, a.id) ORDER BY t.position DESC, t.id DESC`
+	}
+	anchorQuery := `
+		SELECT a.position, (
+			SELECT t.position FROM tasks t
+			WHERE t.tenant_id = a.tenant_id AND t.owner_id = a.owner_id AND t.deleted_at IS NULL
+				AND t.id <> $4 AND ` + neighbour + `
+			LIMIT 1
+		)
+		FROM tasks a
+		WHERE a.id = $1 AND a.tenant_id = $2 AND a.owner_id = $3 AND a.deleted_at IS NULL`
+	rebalanceQuery := `
+		UPDATE tasks t SET position = r.rn
+		FROM (
+			SELECT id, row_number() OVER (ORDER BY position, id) AS rn
+			FROM tasks
+			WHERE tenant_id = $1 AND owner_id = $2 AND deleted_at IS NULL
+		) r
+		WHERE t.id = r.id AND t.position <> r.rn
+		RETURNING t.id, t.list_id, t.parent_id`
+	moveQuery := `
+		UPDATE tasks SET position = $4
+		WHERE id = $1 AND tenant_id = $2 AND owner_id = $3 AND deleted_at IS NULL
+		RETURNING ` + taskColumns
+
+	var task *model.Task
+	err = r.inTx(ctx, "move_task", func(ctx context.Context, tx pgx.Tx) error {
+		q := anchorQuery
+		position, err := movePosition(ctx, tx, q, id, anchorID, caller, before)
+		if errors.Is(err, errNoGap) {
+			q = rebalanceQuery
+			err = rebalance(ctx, tx, q, caller)
+			if err == nil {
+				q = anchorQuery
+				position, err = movePosition(ctx, tx, q, id, anchorID, caller, before)
+			}
+		}
+		if err == nil {
+			q = moveQuery
+			task, err = scanTask(tx.QueryRow(ctx, q, id, caller.TenantID, caller.UserID, position))
+		}
+		if err == nil {
+			task.Items, err = loadItems(ctx, tx, task.ID)
+		}
+		if err == nil {
+			err = writeOutbox(ctx, tx, model.OutboxTaskUpdated, task)
+		}
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				r.logCriticalDBError(ctx, "move_task", q, time.Since(start), err)
+			}
+			return HandlePgxError("move_task", err)
+		}
+		return nil
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.logSlowQuery(ctx, "move_task", duration)
+	return task, nil
+}
+
+// errNoGap reports that two adjacent positions are too close to place a
+// task between them.
+var errNoGap = errors.New("no gap between positions")
+
+func movePosition(ctx context.Context, tx pgx.Tx, q string, id, anchorID uuid.UUID, caller identity.Identity, before bool) (float64, error) {
+	var (
+		anchor    float64
+		neighbour *float64
 	)
+	if err := tx.QueryRow(ctx, q, anchorID, caller.TenantID, caller.UserID, id).Scan(&anchor, &neighbour); err != nil {
+		return 0, err
+	}
+
+	if neighbour == nil {
+		if before {
+			return anchor - 1, nil
+		}
+		return anchor + 1, nil
+	}
+
+	position := anchor + (*neighbour-anchor)/2
+	if position == anchor || position == *neighbour {
+		return 0, errNoGap
+	}
+	return position, nil
+}
+
+// rebalance renumbers the caller's tasks and reports every renumbered task,
+// its list and its ancestors for cache invalidation.
+func rebalance(ctx context.Context, tx pgx.Tx, q string, caller identity.Identity) error {
+	rows, err := tx.Query(ctx, q, caller.TenantID, caller.UserID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var parentIDs []*uuid.UUID
+	for rows.Next() {
+		var (
+			id       uuid.UUID
+			listID   *uuid.UUID
+			parentID *uuid.UUID
+		)
+		if err := rows.Scan(&id, &listID, &parentID); err != nil {
+			return err
+		}
+		deferInvalidation(ctx, id)
+		deferListInvalidation(ctx, listID)
+		parentIDs = append(parentIDs, parentID)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	return deferAncestorInvalidation(ctx, tx, parentIDs...)
+}
 
+// Purge deletes the task for good; its subtasks become top-level tasks.
+func (r *taskRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("purge_task", err)
+	}
+
+	start := time.Now()
+	q := `DELETE FROM tasks WHERE id = $1 AND tenant_id = $2 AND owner_id = $3 RETURNING ` + taskColumns
+
+	err = r.inTx(ctx, "purge_task", func(ctx context.Context, tx pgx.Tx) error {
+		err := detachSubtasks(ctx, tx, id, caller)
+		if err != nil {
+			r.logCriticalDBError(ctx, "purge_task", detachSubtasksQuery, time.Since(start), err)
+			return HandlePgxError("purge_task", err)
+		}
+
+		task, err := scanTask(tx.QueryRow(ctx, q, id, caller.TenantID, caller.UserID))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return WrapError("purge_task", ErrTaskNotFound)
+		}
+		if err == nil {
+			err = writeOutbox(ctx, tx, model.OutboxTaskPurged, task)
+		}
+		if err != nil {
+			r.logCriticalDBError(ctx, "purge_task", q, time.Since(start), err)
+			return HandlePgxError("purge_task", err)
+		}
+		return nil
+	})
 	duration := time.Since(start)
 
 	if err != nil {
-		if !errors.Is(err, pgx.ErrNoRows) {
-			r.logCriticalDBError(ctx, "update_task", q, duration, err)
+		return err
+	}
+
+	r.logSlowQuery(ctx, "purge_task", duration)
+	return nil
+}
+
+// PurgeDeletedBefore hard-deletes soft-deleted tasks of every tenant in
+// batches, so a large backlog does not hold one long-running lock.
+func (r *taskRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time, batchSize int) (int64, error) {
+	q := `
+		DELETE FROM tasks WHERE id IN (
+			SELECT id FROM tasks WHERE deleted_at < $1 LIMIT $2
+		)
+	`
+
+	var total int64
+	for {
+		start := time.Now()
+		commandTag, err := r.db.Exec(ctx, q, cutoff, batchSize)
+		duration := time.Since(start)
+
+		if err != nil {
+			r.logCriticalDBError(ctx, "purge_deleted_tasks", q, duration, err)
+			return total, HandlePgxError("purge_deleted_tasks", err)
+		}
+
+		r.logSlowQuery(ctx, "purge_deleted_tasks", duration)
+
+		total += commandTag.RowsAffected()
+		if commandTag.RowsAffected() < int64(batchSize) {
+			return total, nil
 		}
-		return nil, HandlePgxError("update_task", err)
 	}
+}
 
-	r.logSlowQuery(ctx, "update_task", duration)
-	return &updatedTask, nil
+// missedConditionalWrite explains why a version-guarded write touched no
+// rows: either the task does not exist for the caller or its version moved.
+func missedConditionalWrite(ctx context.Context, q querier, op string, id uuid.UUID, caller identity.Identity, expectedVersion *int64) error {
+	if expectedVersion == nil {
+		return WrapError(op, ErrTaskNotFound)
+	}
+
+	var exists bool
+	err := q.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND tenant_id = $2 AND owner_id = $3 AND deleted_at IS NULL)`,
+		id, caller.TenantID, caller.UserID,
+	).Scan(&exists)
+	if err != nil {
+		return HandlePgxError(op, err)
+	}
+
+	if exists {
+		return WrapError(op, ErrVersionMismatch)
+	}
+	return WrapError(op, ErrTaskNotFound)
 }
 
-func (r *taskRepository) DeleteByID(ctx context.Context, id uuid.UUID) error {
+func (r *taskRepository) Search(ctx context.Context, query string, limit int) ([]*model.SearchResult, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("search_tasks", err)
+	}
+
 	start := time.Now()
-	q := `DELETE FROM tasks WHERE id = $1`
+	// Headlines are expensive, so they are built only for the ranked page.
+	q := `
+		SELECT ` + taskColumns + `, rank,
+			ts_headline('simple', title, query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'),
+			ts_headline('simple', coalesce(description, ''), query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2')
+		FROM (
+			SELECT t.*, ts_rank_cd(t.search_vector, q) AS rank, q AS query
+			FROM tasks t, websearch_to_tsquery('simple', $1) q
+			WHERE t.search_vector @@ q AND t.tenant_id = $2 AND t.owner_id = $3 AND t.deleted_at IS NULL
+			ORDER BY rank DESC, t.created_at DESC
+			LIMIT $4
+		) matches
+		ORDER BY rank DESC, created_at DESC
+	`
+
+	rows, err := r.conn(ctx).Query(ctx, q, query, caller.TenantID, caller.UserID, limit)
+	if err != nil {
+		duration := time.Since(start)
+		r.logCriticalDBError(ctx, "search_tasks", q, duration, err)
+		return nil, HandlePgxError("search_tasks", err)
+	}
+	defer rows.Close()
+
+	var results []*model.SearchResult
+	for rows.Next() {
+		var result model.SearchResult
+		task, err := scanTaskWith(rows, &result.Rank, &result.TitleHighlight, &result.DescriptionHighlight)
+		if err != nil {
+			duration := time.Since(start)
+			r.logCriticalDBError(ctx, "search_tasks_scan", "", duration, err)
+			return nil, HandlePgxError("search_tasks_scan", err)
+		}
+		result.Task = task
+		results = append(results, &result)
+	}
 
-	commandTag, err := r.db.Exec(ctx, q, id)
 	duration := time.Since(start)
+	if err = rows.Err(); err != nil {
+		r.logCriticalDBError(ctx, "search_tasks_iteration", "", duration, err)
+		return nil, HandlePgxError("search_tasks_iteration", err)
+	}
 
+	r.logSlowQuery(ctx, "search_tasks", duration)
+	return results, nil
+}
+
+// ListTags counts the caller's tasks per tag, leaving out deleted tasks.
+func (r *taskRepository) ListTags(ctx context.Context) ([]*model.TagCount, error) {
+	caller, err := identity.FromContext(ctx)
 	if err != nil {
-		r.logCriticalDBError(ctx, "delete_task", q, duration, err)
-		return HandlePgxError("delete_task", err)
+		return nil, WrapError("list_tags", err)
 	}
 
-	if commandTag.RowsAffected() == 0 {
-		return WrapError("delete_task", ErrTaskNotFound)
+	start := time.Now()
+	q := `
+		SELECT tag, COUNT(*)
+		FROM tasks, unnest(tags) AS tag
+		WHERE tenant_id = $1 AND owner_id = $2 AND deleted_at IS NULL
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag
+	`
+
+	rows, err := r.conn(ctx).Query(ctx, q, caller.TenantID, caller.UserID)
+	if err != nil {
+		r.logCriticalDBError(ctx, "list_tags", q, time.Since(start), err)
+		return nil, HandlePgxError("list_tags", err)
 	}
+	defer rows.Close()
 
-	r.logSlowQuery(ctx, "delete_task", duration)
-	return nil
+	counts := []*model.TagCount{}
+	for rows.Next() {
+		var count model.TagCount
+		if err := rows.Scan(&count.Tag, &count.Count); err != nil {
+			return nil, HandlePgxError("list_tags", err)
+		}
+		counts = append(counts, &count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, HandlePgxError("list_tags", err)
+	}
+
+	r.logSlowQuery(ctx, "list_tags", time.Since(start))
+	return counts, nil
 }
 
-func (r *taskRepository) List(ctx context.Context) ([]*model.Task, error) {
+// ListRecurring returns up to limit of the caller's recurring tasks that
+// are due in [start, end) or still open; future occurrences are projected
+// from the open ones.
+func (r *taskRepository) ListRecurring(ctx context.Context, start, end time.Time, limit int) ([]*model.Task, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("list_recurring_tasks", err)
+	}
+
+	began := time.Now()
+	q := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE tenant_id = $1 AND owner_id = $2 AND series_id IS NOT NULL AND deleted_at IS NULL
+			AND ((due_at >= $3 AND due_at < $4) OR (NOT completed AND recurrence <> ''))
+		ORDER BY due_at, id
+		LIMIT $5
+	`
+
+	rows, err := r.conn(ctx).Query(ctx, q, caller.TenantID, caller.UserID, start, end, limit)
+	if err != nil {
+		r.logCriticalDBError(ctx, "list_recurring_tasks", q, time.Since(began), err)
+		return nil, HandlePgxError("list_recurring_tasks", err)
+	}
+	defer rows.Close()
+
+	tasks := []*model.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, HandlePgxError("list_recurring_tasks", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, HandlePgxError("list_recurring_tasks", err)
+	}
+
+	r.logSlowQuery(ctx, "list_recurring_tasks", time.Since(began))
+	return tasks, nil
+}
+
+var taskSortColumns = map[model.SortField]string{
+	model.SortByCreatedAt: "created_at",
+	model.SortByUpdatedAt: "updated_at",
+	model.SortByTitle:     "title",
+	model.SortByPosition:  "position",
+	model.SortByPriority:  "priority",
+}
+
+func (r *taskRepository) List(ctx context.Context, opts model.ListOptions) (*model.TaskPage, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("list_tasks", err)
+	}
+
 	start := time.Now()
-	q := `SELECT id, title, description, completed, created_at, updated_at FROM tasks ORDER BY created_at DESC`
+	limit := opts.Limit()
+
+	q, args, err := buildListQuery(caller, opts, limit)
+	if err != nil {
+		return nil, WrapError("list_tasks", err)
+	}
 
-	rows, err := r.db.Query(ctx, q)
+	rows, err := r.conn(ctx).Query(ctx, q, args...)
 	if err != nil {
 		duration := time.Since(start)
 		r.logCriticalDBError(ctx, "list_tasks", q, duration, err)
@@ -149,17 +715,13 @@ func (r *taskRepository) List(ctx context.Context) ([]*model.Task, error) {
 
 	var tasks []*model.Task
 	for rows.Next() {
-		var task model.Task
-		err := rows.Scan(
-			&task.ID, &task.Title, &task.Description,
-			&task.Completed, &task.CreatedAt, &task.UpdatedAt,
-		)
+		task, err := scanTask(rows)
 		if err != nil {
 			duration := time.Since(start)
 			r.logCriticalDBError(ctx, "list_tasks_scan", "", duration, err)
 			return nil, HandlePgxError("list_tasks_scan", err)
 		}
-		tasks = append(tasks, &task)
+		tasks = append(tasks, task)
 	}
 
 	duration := time.Since(start)
@@ -168,11 +730,158 @@ func (r *taskRepository) List(ctx context.Context) ([]*model.Task, error) {
 		return nil, HandlePgxError("list_tasks_iteration", err)
 	}
 
+	page := &model.TaskPage{Tasks: tasks}
+	if len(tasks) > limit {
+		page.Tasks = tasks[:limit]
+		page.NextCursor = model.CursorAfter(page.Tasks[len(page.Tasks)-1], opts.Sort)
+	}
+
 	r.logSlowQuery(ctx, "list_tasks", duration)
-	return tasks, nil
+	return page, nil
+}
+
+func buildListQuery(caller identity.Identity, opts model.ListOptions, limit int) (string, []interface{}, error) {
+	sortColumn, ok := taskSortColumns[opts.Sort.Field]
+	if !ok {
+		return "", nil, ErrInvalidData
+	}
+	direction, cmp := "ASC", ">"
+	if opts.Sort.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	var (
+		where []string
+		args  []interface{}
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	f := opts.Filter
+	if f.AssignedToMe {
+		where = append(where, "tenant_id = "+arg(caller.TenantID),
+			"id IN (SELECT task_id FROM task_assignees WHERE user_id = "+arg(caller.UserID)+")")
+	} else {
+		where = append(where, "tenant_id = "+arg(caller.TenantID), "owner_id = "+arg(caller.UserID))
+	}
+	if !f.IncludeDeleted {
+		where = append(where, "deleted_at IS NULL")
+	}
+	if f.Completed != nil {
+		where = append(where, "completed = "+arg(*f.Completed))
+	}
+	if f.CreatedAfter != nil {
+		where = append(where, "created_at >= "+arg(*f.CreatedAfter))
+	}
+	if f.CreatedBefore != nil {
+		where = append(where, "created_at < "+arg(*f.CreatedBefore))
+	}
+	if f.UpdatedAfter != nil {
+		where = append(where, "updated_at >= "+arg(*f.UpdatedAfter))
+	}
+	if f.UpdatedBefore != nil {
+		where = append(where, "updated_at < "+arg(*f.UpdatedBefore))
+	}
+	if f.Overdue {
+		where = append(where, "NOT completed", "due_at < NOW()")
+	}
+	if len(f.TagsAny) > 0 {
+		where = append(where, "tags && "+arg(f.TagsAny)+"::text[]")
+	}
+	if len(f.TagsAll) > 0 {
+		where = append(where, "tags @> "+arg(f.TagsAll)+"::text[]")
+	}
+	if f.ListID != nil {
+		where = append(where, "list_id = "+arg(*f.ListID))
+	}
+	for _, filter := range f.CustomFields {
+		condition, err := customFieldCondition(filter, arg)
+		if err != nil {
+			return "", nil, err
+		}
+		where = append(where, condition)
+	}
+	if f.Query != "" {
+		pattern := arg("%" + escapeLike(f.Query) + "%")
+		where = append(where, fmt.Sprintf("(title ILIKE %s OR description ILIKE %s)", pattern, pattern))
+	}
+
+	if opts.Cursor != nil {
+		value, err := opts.Cursor.SortValue()
+		if err != nil {
+			return "", nil, err
+		}
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s, %s)", sortColumn, cmp, arg(value), arg(opts.Cursor.ID)))
+	}
+
+	q := `SELECT ` + taskColumns + ` FROM tasks WHERE ` + strings.Join(where, " AND ")
+
+	// One extra row tells us whether another page follows.
+	q += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", sortColumn, direction, direction, arg(limit+1))
+
+	return q, args, nil
+}
+
+var customFieldOperators = map[model.CustomFieldOp]string{
+	model.CustomFieldLt:  "<",
+	model.CustomFieldLte: "<=",
+	model.CustomFieldGt:  ">",
+	model.CustomFieldGte: ">=",
+}
+
+// customFieldCondition renders a custom field filter the service has
+// validated. Ranges only compare values of the filter's JSON type, as
+// another list may define the same key with another type.
+func customFieldCondition(filter model.CustomFieldFilter, arg func(interface{}) string) (string, error) {
+	switch filter.Op {
+	case model.CustomFieldSet:
+		return "custom_fields ? " + arg(filter.Key), nil
+	case model.CustomFieldEq:
+		value, err := json.Marshal(map[string]interface{}{filter.Key: filter.Value})
+		if err != nil {
+			return "", ErrInvalidData
+		}
+		return "custom_fields @> " + arg(string(value)) + "::jsonb", nil
+	}
+
+	op, ok := customFieldOperators[filter.Op]
+	if !ok {
+		return "", ErrInvalidData
+	}
+	key := arg(filter.Key)
+
+	switch value := filter.Value.(type) {
+	case float64:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", ErrInvalidData
+		}
+		return fmt.Sprintf("(jsonb_typeof(custom_fields -> %s) = 'number' AND custom_fields -> %s %s %s::jsonb)",
+			key, key, op, arg(string(data))), nil
+	case string:
+		// Dates are YYYY-MM-DD, which sort bytewise in date order.
+		return fmt.Sprintf(`(jsonb_typeof(custom_fields -> %s) = 'string' AND (custom_fields ->> %s) COLLATE "C" %s %s)`,
+			key, key, op, arg(value)), nil
+	default:
+		return "", ErrInvalidData
+	}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
 func (r *taskRepository) logCriticalDBError(ctx context.Context, operation, query string, duration time.Duration, err error) {
+	logCriticalDBError(ctx, operation, query, duration, err)
+}
+
+func (r *taskRepository) logSlowQuery(ctx context.Context, operation string, duration time.Duration) {
+	logSlowQuery(ctx, operation, duration)
+}
+
+func logCriticalDBError(ctx context.Context, operation, query string, duration time.Duration, err error) {
 	args := []interface{}{}
 	logger.LogDatabaseQuery(ctx, query, args, duration, err)
 
@@ -183,7 +892,7 @@ func (r *taskRepository) logCriticalDBError(ctx context.Context, operation, quer
 	)
 }
 
-func (r *taskRepository) logSlowQuery(ctx context.Context, operation string, duration time.Duration) {
+func logSlowQuery(ctx context.Context, operation string, duration time.Duration) {
 	threshold := 500 * time.Millisecond
 	if duration > threshold {
 		logger.LogSlowOperation(ctx, operation, duration, threshold)
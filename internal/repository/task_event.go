@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TaskEventRepository reads the change log that the record_task_event
+// trigger writes for every insert, update and delete on tasks.
+type TaskEventRepository interface {
+	Head(ctx context.Context) (model.EventPosition, error)
+	ListAfter(ctx context.Context, after model.EventPosition, limit int) ([]*model.TaskEvent, error)
+	Expired(ctx context.Context, position model.EventPosition) (bool, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time, batchSize int) (int64, error)
+}
+
+type taskEventRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTaskEventRepository(db *pgxpool.Pool) TaskEventRepository {
+	return &taskEventRepository{
+		db: db,
+	}
+}
+
+// taskSnapshot mirrors the to_jsonb(tasks) row stored with each event and
+// is also the payload of outbox events.
+type taskSnapshot struct {
+	ID                 uuid.UUID  `json:"id"`
+	TenantID           string     `json:"tenant_id"`
+	OwnerID            string     `json:"owner_id"`
+	Title              string     `json:"title"`
+	Description        *string    `json:"description"`
+	Completed          bool       `json:"completed"`
+	CompletedFromItems bool       `json:"completed_from_items"`
+	Version            int64      `json:"version"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	DeletedAt          *time.Time `json:"deleted_at"`
+	DueAt              *time.Time `json:"due_at"`
+	RemindAt           *time.Time `json:"remind_at"`
+	Recurrence         string     `json:"recurrence"`
+	SeriesID           *uuid.UUID `json:"series_id"`
+	RecurrenceStart    *time.Time `json:"recurrence_start"`
+	Tags               []string   `json:"tags"`
+	Priority           int16      `json:"priority"`
+	Position           float64    `json:"position"`
+	ListID             *uuid.UUID `json:"list_id"`
+	ParentID           *uuid.UUID `json:"parent_id"`
+
+	CustomFields map[string]interface{} `json:"custom_fields"`
+}
+
+func newTaskSnapshot(task *model.Task) taskSnapshot {
+	return taskSnapshot{
+		ID:                 task.ID,
+		TenantID:           task.TenantID,
+		OwnerID:            task.OwnerID,
+		Title:              task.Title,
+		Description:        &task.Description,
+		Completed:          task.Completed,
+		CompletedFromItems: task.CompletedFromItems,
+		Version:            task.Version,
+		CreatedAt:          task.CreatedAt,
+		UpdatedAt:          task.UpdatedAt,
+		DeletedAt:          task.DeletedAt,
+		DueAt:              task.DueAt,
+		RemindAt:           task.RemindAt,
+		Recurrence:         task.Recurrence,
+		SeriesID:           task.SeriesID,
+		RecurrenceStart:    task.RecurrenceStart,
+		Tags:               task.Tags,
+		Priority:           int16(task.Priority),
+		Position:           task.Position,
+		ListID:             task.ListID,
+		ParentID:           task.ParentID,
+		CustomFields:       task.CustomFields,
+	}
+}
+
+func (s taskSnapshot) toTask() *model.Task {
+	task := &model.Task{
+		ID:                 s.ID,
+		TenantID:           s.TenantID,
+		OwnerID:            s.OwnerID,
+		Title:              s.Title,
+		Completed:          s.Completed,
+		CompletedFromItems: s.CompletedFromItems,
+		Version:            s.Version,
+		CreatedAt:          s.CreatedAt,
+		UpdatedAt:          s.UpdatedAt,
+		DeletedAt:          s.DeletedAt,
+		DueAt:              s.DueAt,
+		RemindAt:           s.RemindAt,
+		Recurrence:         s.Recurrence,
+		SeriesID:           s.SeriesID,
+		RecurrenceStart:    s.RecurrenceStart,
+		Tags:               s.Tags,
+		Priority:           model.Priority(s.Priority),
+		Position:           s.Position,
+		ListID:             s.ListID,
+		ParentID:           s.ParentID,
+		CustomFields:       s.CustomFields,
+	}
+	if s.Description != nil {
+		task.Description = *s.Description
+	}
+	return task
+}
+
+// Head returns a position just before every transaction that is still in
+// flight, so that watching from it yields exactly the changes that become
+// visible from now on.
+func (r *taskEventRepository) Head(ctx context.Context) (model.EventPosition, error) {
+	start := time.Now()
+	q := `SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint`
+
+	var xmin int64
+	err := r.db.QueryRow(ctx, q).Scan(&xmin)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "task_events_head", q, duration, err)
+		return model.EventPosition{}, HandlePgxError("task_events_head", err)
+	}
+
+	logSlowQuery(ctx, "task_events_head", duration)
+	return model.EventPosition{TxID: xmin - 1, Seq: math.MaxInt64}, nil
+}
+
+// ListAfter returns the caller's events after the given position. Events of
+// transactions that are not yet older than every in-flight transaction are
+// held back, since an earlier position could still commit behind them.
+func (r *taskEventRepository) ListAfter(ctx context.Context, after model.EventPosition, limit int) ([]*model.TaskEvent, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("list_task_events", err)
+	}
+
+	start := time.Now()
+	q := `
+		SELECT txid::text::bigint, seq, event_type, task, created_at
+		FROM task_events
+		WHERE tenant_id = $1 AND owner_id = $2
+			AND (txid, seq) > ($3::bigint::text::xid8, $4)
+			AND txid < pg_snapshot_xmin(pg_current_snapshot())
+		ORDER BY txid, seq
+		LIMIT $5
+	`
+
+	rows, err := r.db.Query(ctx, q, caller.TenantID, caller.UserID, after.TxID, after.Seq, limit)
+	if err != nil {
+		logCriticalDBError(ctx, "list_task_events", q, time.Since(start), err)
+		return nil, HandlePgxError("list_task_events", err)
+	}
+	defer rows.Close()
+
+	events := []*model.TaskEvent{}
+	for rows.Next() {
+		var (
+			event    model.TaskEvent
+			payload  []byte
+			snapshot taskSnapshot
+		)
+		err := rows.Scan(&event.Position.TxID, &event.Position.Seq, &event.Type, &payload, &event.OccurredAt)
+		if err != nil {
+			return nil, HandlePgxError("list_task_events", err)
+		}
+		if err := json.Unmarshal(payload, &snapshot); err != nil {
+			return nil, WrapError("list_task_events", err)
+		}
+		event.Task = snapshot.toTask()
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, HandlePgxError("list_task_events", err)
+	}
+
+	duration := time.Since(start)
+	logSlowQuery(ctx, "list_task_events", duration)
+	return events, nil
+}
+
+// Expired reports whether the event at position has been trimmed, in which
+// case later events may be gone as well and the client has to resync.
+func (r *taskEventRepository) Expired(ctx context.Context, position model.EventPosition) (bool, error) {
+	start := time.Now()
+	q := `SELECT NOT EXISTS (SELECT 1 FROM task_events WHERE seq = $1)`
+
+	var expired bool
+	err := r.db.QueryRow(ctx, q, position.Seq).Scan(&expired)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "task_event_expired", q, duration, err)
+		return false, HandlePgxError("task_event_expired", err)
+	}
+
+	logSlowQuery(ctx, "task_event_expired", duration)
+	return expired, nil
+}
+
+func (r *taskEventRepository) DeleteBefore(ctx context.Context, cutoff time.Time, batchSize int) (int64, error) {
+	q := `
+		DELETE FROM task_events WHERE seq IN (
+			SELECT seq FROM task_events WHERE created_at < $1 LIMIT $2
+		)
+	`
+
+	var total int64
+	for {
+		start := time.Now()
+		commandTag, err := r.db.Exec(ctx, q, cutoff, batchSize)
+		duration := time.Since(start)
+
+		if err != nil {
+			logCriticalDBError(ctx, "trim_task_events", q, duration, err)
+			return total, HandlePgxError("trim_task_events", err)
+		}
+
+		logSlowQuery(ctx, "trim_task_events", duration)
+
+		total += commandTag.RowsAffected()
+		if commandTag.RowsAffected() < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TaskListRepository interface {
+	Create(ctx context.Context, list *model.TaskList) (*model.TaskList, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*model.TaskList, error)
+	Update(ctx context.Context, list *model.TaskList) (*model.TaskList, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, limit int, cursor *model.PageCursor) ([]*model.TaskList, *model.PageCursor, error)
+}
+
+type taskListRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTaskListRepository(db *pgxpool.Pool) TaskListRepository {
+	return &taskListRepository{
+		db: db,
+	}
+}
+
+const taskListColumns = `id, tenant_id, owner_id, name, description, created_at, updated_at`
+
+func scanTaskList(row pgx.Row) (*model.TaskList, error) {
+	var list model.TaskList
+	var description *string
+	err := row.Scan(
+		&list.ID, &list.TenantID, &list.OwnerID, &list.Name, &description,
+		&list.CreatedAt, &list.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if description != nil {
+		list.Description = *description
+	}
+	return &list, nil
+}
+
+// handleTaskListError maps a missing row to ErrListNotFound rather than
+// ErrTaskNotFound.
+func handleTaskListError(op string, err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return WrapError(op, ErrListNotFound)
+	}
+	return HandlePgxError(op, err)
+}
+
+func (r *taskListRepository) Create(ctx context.Context, list *model.TaskList) (*model.TaskList, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("create_task_list", err)
+	}
+
+	start := time.Now()
+	q := `
+		INSERT INTO task_lists (id, tenant_id, owner_id, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + taskListColumns
+
+	created, err := scanTaskList(txOrPool(ctx, r.db).QueryRow(ctx, q,
+		list.ID, caller.TenantID, caller.UserID, list.Name, list.Description, list.CreatedAt, list.UpdatedAt,
+	))
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "create_task_list", q, duration, err)
+		return nil, HandlePgxError("create_task_list", err)
+	}
+
+	logSlowQuery(ctx, "create_task_list", duration)
+	return created, nil
+}
+
+func (r *taskListRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.TaskList, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("get_task_list", err)
+	}
+
+	start := time.Now()
+	q := `SELECT ` + taskListColumns + ` FROM task_lists WHERE id = $1 AND tenant_id = $2 AND owner_id = $3`
+
+	list, err := scanTaskList(txOrPool(ctx, r.db).QueryRow(ctx, q, id, caller.TenantID, caller.UserID))
+	duration := time.Since(start)
+
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logCriticalDBError(ctx, "get_task_list", q, duration, err)
+		}
+		return nil, handleTaskListError("get_task_list", err)
+	}
+
+	logSlowQuery(ctx, "get_task_list", duration)
+	return list, nil
+}
+
+func (r *taskListRepository) Update(ctx context.Context, list *model.TaskList) (*model.TaskList, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("update_task_list", err)
+	}
+
+	start := time.Now()
+	q := `
+		UPDATE task_lists SET name = $4, description = $5
+		WHERE id = $1 AND tenant_id = $2 AND owner_id = $3
+		RETURNING ` + taskListColumns
+
+	updated, err := scanTaskList(txOrPool(ctx, r.db).QueryRow(ctx, q,
+		list.ID, caller.TenantID, caller.UserID, list.Name, list.Description,
+	))
+	duration := time.Since(start)
+
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logCriticalDBError(ctx, "update_task_list", q, duration, err)
+		}
+		return nil, handleTaskListError("update_task_list", err)
+	}
+
+	logSlowQuery(ctx, "update_task_list", duration)
+	return updated, nil
+}
+
+// Delete removes an empty list. Tasks in the trash that still point at it
+// are taken out of it by the foreign key.
+func (r *taskListRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("delete_task_list", err)
+	}
+
+	start := time.Now()
+	q := `
+		WITH target AS (
+			SELECT id FROM task_lists
+			WHERE id = $1 AND tenant_id = $2 AND owner_id = $3
+			FOR UPDATE
+		), deleted AS (
+			DELETE FROM task_lists
+			WHERE id IN (SELECT id FROM target)
+				AND NOT EXISTS (SELECT 1 FROM tasks WHERE list_id = $1 AND deleted_at IS NULL)
+			RETURNING id
+		)
+		SELECT EXISTS (SELECT 1 FROM target), EXISTS (SELECT 1 FROM deleted)
+	`
+
+	var found, deleted bool
+	err = txOrPool(ctx, r.db).QueryRow(ctx, q, id, caller.TenantID, caller.UserID).Scan(&found, &deleted)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "delete_task_list", q, duration, err)
+		return HandlePgxError("delete_task_list", err)
+	}
+
+	logSlowQuery(ctx, "delete_task_list", duration)
+	switch {
+	case !found:
+		return WrapError("delete_task_list", ErrListNotFound)
+	case !deleted:
+		return WrapError("delete_task_list", ErrListNotEmpty)
+	}
+	return nil
+}
+
+// List returns up to limit of the caller's lists after cursor, oldest
+// first, and the cursor of the next page if there is one.
+func (r *taskListRepository) List(ctx context.Context, limit int, cursor *model.PageCursor) ([]*model.TaskList, *model.PageCursor, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, nil, WrapError("list_task_lists", err)
+	}
+
+	start := time.Now()
+	q := `
+		SELECT ` + taskListColumns + `
+		FROM task_lists
+		WHERE tenant_id = $1 AND owner_id = $2
+			AND ($3::timestamptz IS NULL OR (created_at, id) > ($3, $4))
+		ORDER BY created_at, id
+		LIMIT $5
+	`
+
+	var after *time.Time
+	afterID := uuid.Nil
+	if cursor != nil {
+		value, err := cursor.SortValue()
+		if err != nil {
+			return nil, nil, WrapError("list_task_lists", err)
+		}
+		t := value.(time.Time)
+		after, afterID = &t, cursor.ID
+	}
+
+	rows, err := txOrPool(ctx, r.db).Query(ctx, q, caller.TenantID, caller.UserID, after, afterID, limit+1)
+	if err != nil {
+		logCriticalDBError(ctx, "list_task_lists", q, time.Since(start), err)
+		return nil, nil, HandlePgxError("list_task_lists", err)
+	}
+	defer rows.Close()
+
+	lists := []*model.TaskList{}
+	for rows.Next() {
+		list, err := scanTaskList(rows)
+		if err != nil {
+			return nil, nil, HandlePgxError("list_task_lists", err)
+		}
+		lists = append(lists, list)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, HandlePgxError("list_task_lists", err)
+	}
+
+	var next *model.PageCursor
+	if len(lists) > limit {
+		lists = lists[:limit]
+		next = model.TaskListCursor(lists[limit-1])
+	}
+
+	logSlowQuery(ctx, "list_task_lists", time.Since(start))
+	return lists, next, nil
+}
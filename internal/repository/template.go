@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrTemplateNotFound = errors.New("task template not found")
+
+type TemplateRepository interface {
+	Create(ctx context.Context, template *model.TaskTemplate) (*model.TaskTemplate, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*model.TaskTemplate, error)
+	// Update replaces everything but the id and creation time of the
+	// template.
+	Update(ctx context.Context, template *model.TaskTemplate) (*model.TaskTemplate, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, limit int, cursor *model.PageCursor) ([]*model.TaskTemplate, *model.PageCursor, error)
+}
+
+type templateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTemplateRepository(db *pgxpool.Pool) TemplateRepository {
+	return &templateRepository{
+		db: db,
+	}
+}
+
+const templateColumns = `id, tenant_id, owner_id, title, description, priority, tags, list_id, custom_fields, items, subtasks, created_at, updated_at`
+
+func scanTemplate(row pgx.Row) (*model.TaskTemplate, error) {
+	var template model.TaskTemplate
+	var description *string
+	err := row.Scan(
+		&template.ID, &template.TenantID, &template.OwnerID, &template.Title, &description,
+		&template.Priority, &template.Tags, &template.ListID, &template.CustomFields,
+		&template.Items, &template.Subtasks, &template.CreatedAt, &template.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if description != nil {
+		template.Description = *description
+	}
+	return &template, nil
+}
+
+// templateArgs returns the columns a template is written with, never nil
+// as none of them take NULL.
+func templateArgs(template *model.TaskTemplate) (tags []string, customFields map[string]interface{}, items []string, subtasks []model.TemplateSubtask) {
+	tags, items, subtasks = template.Tags, template.Items, template.Subtasks
+	if tags == nil {
+		tags = []string{}
+	}
+	if items == nil {
+		items = []string{}
+	}
+	if subtasks == nil {
+		subtasks = []model.TemplateSubtask{}
+	}
+	for i := range subtasks {
+		if subtasks[i].Items == nil {
+			subtasks[i].Items = []string{}
+		}
+	}
+	return tags, model.CopyCustomFields(template.CustomFields), items, subtasks
+}
+
+// handleTemplateError maps a missing row to ErrTemplateNotFound rather than
+// ErrTaskNotFound.
+func handleTemplateError(op string, err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return WrapError(op, ErrTemplateNotFound)
+	}
+	return HandlePgxError(op, err)
+}
+
+func (r *templateRepository) Create(ctx context.Context, template *model.TaskTemplate) (*model.TaskTemplate, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("create_template", err)
+	}
+
+	start := time.Now()
+	q := `
+		INSERT INTO task_templates (id, tenant_id, owner_id, title, description, priority, tags, list_id,
+			custom_fields, items, subtasks, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING ` + templateColumns
+
+	tags, customFields, items, subtasks := templateArgs(template)
+	created, err := scanTemplate(txOrPool(ctx, r.db).QueryRow(ctx, q,
+		template.ID, caller.TenantID, caller.UserID, template.Title, template.Description, template.Priority,
+		tags, template.ListID, customFields, items, subtasks, template.CreatedAt, template.UpdatedAt,
+	))
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "create_template", q, duration, err)
+		return nil, HandlePgxError("create_template", err)
+	}
+
+	logSlowQuery(ctx, "create_template", duration)
+	return created, nil
+}
+
+func (r *templateRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.TaskTemplate, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("get_template", err)
+	}
+
+	start := time.Now()
+	q := `SELECT ` + templateColumns + ` FROM task_templates WHERE id = $1 AND tenant_id = $2 AND owner_id = $3`
+
+	template, err := scanTemplate(txOrPool(ctx, r.db).QueryRow(ctx, q, id, caller.TenantID, caller.UserID))
+	duration := time.Since(start)
+
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logCriticalDBError(ctx, "get_template", q, duration, err)
+		}
+		return nil, handleTemplateError("get_template", err)
+	}
+
+	logSlowQuery(ctx, "get_template", duration)
+	return template, nil
+}
+
+func (r *templateRepository) Update(ctx context.Context, template *model.TaskTemplate) (*model.TaskTemplate, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, WrapError("update_template", err)
+	}
+
+	start := time.Now()
+	q := `
+		UPDATE task_templates SET title = $4, description = $5, priority = $6, tags = $7, list_id = $8,
+			custom_fields = $9, items = $10, subtasks = $11
+		WHERE id = $1 AND tenant_id = $2 AND owner_id = $3
+		RETURNING ` + templateColumns
+
+	tags, customFields, items, subtasks := templateArgs(template)
+	updated, err := scanTemplate(txOrPool(ctx, r.db).QueryRow(ctx, q,
+		template.ID, caller.TenantID, caller.UserID, template.Title, template.Description, template.Priority,
+		tags, template.ListID, customFields, items, subtasks,
+	))
+	duration := time.Since(start)
+
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logCriticalDBError(ctx, "update_template", q, duration, err)
+		}
+		return nil, handleTemplateError("update_template", err)
+	}
+
+	logSlowQuery(ctx, "update_template", duration)
+	return updated, nil
+}
+
+func (r *templateRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return WrapError("delete_template", err)
+	}
+
+	start := time.Now()
+	q := `DELETE FROM task_templates WHERE id = $1 AND tenant_id = $2 AND owner_id = $3`
+
+	tag, err := txOrPool(ctx, r.db).Exec(ctx, q, id, caller.TenantID, caller.UserID)
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "delete_template", q, duration, err)
+		return HandlePgxError("delete_template", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return WrapError("delete_template", ErrTemplateNotFound)
+	}
+
+	logSlowQuery(ctx, "delete_template", duration)
+	return nil
+}
+
+// List returns up to limit of the caller's templates after cursor, oldest
+// first, and the cursor of the next page if there is one.
+func (r *templateRepository) List(ctx context.Context, limit int, cursor *model.PageCursor) ([]*model.TaskTemplate, *model.PageCursor, error) {
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		return nil, nil, WrapError("list_templates", err)
+	}
+
+	start := time.Now()
+	q := `
+		SELECT ` + templateColumns + `
+		FROM task_templates
+		WHERE tenant_id = $1 AND owner_id = $2
+			AND ($3::timestamptz IS NULL OR (created_at, id) > ($3, $4))
+		ORDER BY created_at, id
+		LIMIT $5
+	`
+
+	var after *time.Time
+	afterID := uuid.Nil
+	if cursor != nil {
+		value, err := cursor.SortValue()
+		if err != nil {
+			return nil, nil, WrapError("list_templates", err)
+		}
+		t := value.(time.Time)
+		after, afterID = &t, cursor.ID
+	}
+
+	templates := []*model.TaskTemplate{}
+	err = collect(ctx, txOrPool(ctx, r.db), q, []interface{}{caller.TenantID, caller.UserID, after, afterID, limit + 1}, func(row pgx.Rows) error {
+		template, err := scanTemplate(row)
+		if err != nil {
+			return err
+		}
+		templates = append(templates, template)
+		return nil
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		logCriticalDBError(ctx, "list_templates", q, duration, err)
+		return nil, nil, HandlePgxError("list_templates", err)
+	}
+
+	var next *model.PageCursor
+	if len(templates) > limit {
+		templates = templates[:limit]
+		next = model.TaskTemplateCursor(templates[limit-1])
+	}
+
+	logSlowQuery(ctx, "list_templates", duration)
+	return templates, next, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type txKey struct{}
+
+// InTx reports whether ctx carries a transaction opened by WithTx.
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return ok
+}
+
+// WithTx runs fn in one transaction. Repository calls made with the context
+// passed to fn join that transaction; nested transactions become savepoints.
+// The transaction commits if fn returns nil and rolls back otherwise.
+func (r *taskRepository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.inTx(ctx, "with_tx", func(ctx context.Context, tx pgx.Tx) error {
+		return fn(ctx)
+	})
+}
+
+// conn returns the transaction carried by ctx, or the pool.
+func (r *taskRepository) conn(ctx context.Context) querier {
+	return txOrPool(ctx, r.db)
+}
+
+// txOrPool lets repositories other than taskRepository join a transaction
+// opened by WithTx.
+func txOrPool(ctx context.Context, db *pgxpool.Pool) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// begin opens a transaction, or a savepoint inside the one carried by ctx.
+// New transactions carry the audit context for the history trigger.
+func (r *taskRepository) begin(ctx context.Context) (pgx.Tx, error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx.Begin(ctx)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := setAuditContext(ctx, tx); err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+	return tx, nil
+}
+
+// inTx runs fn in a transaction that is also carried by the context fn
+// receives. Errors returned by fn are passed through unchanged.
+func (r *taskRepository) inTx(ctx context.Context, op string, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	start := time.Now()
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		r.logCriticalDBError(ctx, op, "BEGIN", time.Since(start), err)
+		return HandlePgxError(op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx), tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logCriticalDBError(ctx, op, "COMMIT", time.Since(start), err)
+		return HandlePgxError(op, err)
+	}
+	return nil
+}
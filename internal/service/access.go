@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+	"github.com/google/uuid"
+)
+
+func (s *taskService) ShareTask(ctx context.Context, req *pb.ShareTaskRequest) (*pb.TaskAccessResponse, error) {
+	start := time.Now()
+	operation := "ShareTask"
+
+	grant, err := model.ShareTaskRequestFromProto(req)
+	if err != nil {
+		serviceErr := accessRequestError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, grant.TaskID, model.PermissionOwner)
+	if serviceErr == nil && grant.UserID == identity.Actor(ctx) {
+		serviceErr = errors.ErrInvalidUserId
+	}
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, grant.TaskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	err = s.accessRepo.Grant(ctx, grant)
+	return s.accessResponse(ctx, operation, grant.TaskID, start, err)
+}
+
+func (s *taskService) UnshareTask(ctx context.Context, req *pb.UnshareTaskRequest) (*pb.TaskAccessResponse, error) {
+	return s.changeAccess(ctx, "UnshareTask", req.GetTaskId(), req.GetUserId(), model.PermissionOwner, s.accessRepo.Revoke)
+}
+
+func (s *taskService) AssignTask(ctx context.Context, req *pb.AssignTaskRequest) (*pb.TaskAccessResponse, error) {
+	return s.changeAccess(ctx, "AssignTask", req.GetTaskId(), req.GetUserId(), model.PermissionOwner, s.accessRepo.Assign)
+}
+
+func (s *taskService) UnassignTask(ctx context.Context, req *pb.AssignTaskRequest) (*pb.TaskAccessResponse, error) {
+	return s.changeAccess(ctx, "UnassignTask", req.GetTaskId(), req.GetUserId(), model.PermissionOwner, s.accessRepo.Unassign)
+}
+
+func (s *taskService) GetTaskAccess(ctx context.Context, req *pb.GetTaskAccessRequest) (*pb.TaskAccessResponse, error) {
+	start := time.Now()
+	operation := "GetTaskAccess"
+
+	id, err := uuid.Parse(req.GetTaskId())
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTaskId, operation)
+		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, id, model.PermissionRead)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, id.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	return s.accessResponse(ctx, operation, id, start, nil)
+}
+
+func (s *taskService) changeAccess(ctx context.Context, operation, rawTaskID, rawUserID string, required model.Permission, change func(context.Context, uuid.UUID, string) error) (*pb.TaskAccessResponse, error) {
+	start := time.Now()
+
+	taskID, userID, err := model.TaskUserFromProto(rawTaskID, rawUserID)
+	if err != nil {
+		serviceErr := accessRequestError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, taskID, required)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	err = change(ctx, taskID, userID)
+	return s.accessResponse(ctx, operation, taskID, start, err)
+}
+
+// accessResponse reports the outcome of an access change together with the
+// task's access after it.
+func (s *taskService) accessResponse(ctx context.Context, operation string, taskID uuid.UUID, start time.Time, err error) (*pb.TaskAccessResponse, error) {
+	var access *model.TaskAccess
+	if err == nil {
+		access, err = s.accessRepo.Get(ctx, taskID)
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, taskID.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, taskID.String(), duration, nil)
+
+	return &pb.TaskAccessResponse{
+		Access: model.TaskAccessToProto(access),
+	}, nil
+}
+
+// authorize fails unless the caller's permission on the task includes
+// required. The returned context is scoped to the task's owner, so that the
+// owner-scoped repositories reach a task shared with the caller while task
+// history still records the caller as the actor.
+func (s *taskService) authorize(ctx context.Context, id uuid.UUID, required model.Permission) (context.Context, *errors.ServiceError) {
+	access, err := s.accessRepo.Permissions(ctx, []uuid.UUID{id})
+	if err != nil {
+		return ctx, errors.WrapRepositoryError(err)
+	}
+
+	task, ok := access[id]
+	if !ok {
+		return ctx, errors.ErrTaskNotFound
+	}
+	if !task.Permission.Allows(required) {
+		return ctx, errors.ErrPermissionDenied
+	}
+	return identity.OnBehalfOf(ctx, task.OwnerID), nil
+}
+
+// rejectForeign fails the items of a batch that target tasks of other
+// owners, since a batch runs in the caller's own scope, and reports which
+// of the given items are kept.
+func (s *taskService) rejectForeign(ctx context.Context, batch *batchRequest, ids []uuid.UUID) ([]bool, error) {
+	keep := make([]bool, len(ids))
+	if len(ids) == 0 {
+		return keep, nil
+	}
+
+	access, err := s.accessRepo.Permissions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := batch.indexes[:0]
+	for j, id := range ids {
+		if task, ok := access[id]; ok && task.Permission != model.PermissionOwner {
+			batch.invalid[batch.indexes[j]] = errors.ErrPermissionDenied
+			continue
+		}
+		keep[j] = true
+		indexes = append(indexes, batch.indexes[j])
+	}
+	batch.indexes = indexes
+	return keep, nil
+}
+
+// updatePermission is what an update needs: filing the task in a list,
+// nesting it and completing its subtasks along with it are left to the
+// owner.
+func updatePermission(req *pb.UpdateTaskRequest) model.Permission {
+	if req.ListId != "" || req.ClearListId || req.ParentId != "" || req.ClearParentId || req.Cascade {
+		return model.PermissionOwner
+	}
+	return model.PermissionEdit
+}
+
+func accessRequestError(err error) *errors.ServiceError {
+	switch {
+	case stderrors.Is(err, model.ErrInvalidUserID):
+		return errors.ErrInvalidUserId
+	case stderrors.Is(err, model.ErrInvalidGrant):
+		return errors.ErrInvalidGrant
+	default:
+		return errors.ErrInvalidTaskId
+	}
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+	"github.com/google/uuid"
+)
+
+// UploadAttachment spools the contents to a temporary file while checking
+// them against the size limit and the owner's quota, so that nothing
+// reaches the blob store before the whole upload is known to fit. The
+// quota is checked again when the attachment is recorded, since other
+// uploads may have finished in the meantime.
+func (s *taskService) UploadAttachment(stream pb.TaskService_UploadAttachmentServer) error {
+	ctx := stream.Context()
+	start := time.Now()
+	operation := "UploadAttachment"
+
+	first, err := stream.Recv()
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if first.GetMetadata() == nil {
+		logger.LogError(ctx, errors.ErrInvalidUpload, operation)
+		return errors.ErrInvalidUpload.ToGRPCStatus()
+	}
+
+	attachment, err := model.AttachmentMetadataFromProto(first.GetMetadata())
+	if err != nil {
+		serviceErr := attachmentRequestError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return serviceErr.ToGRPCStatus()
+	}
+	taskID := attachment.TaskID.String()
+
+	ctx, serviceErr := s.authorize(ctx, attachment.TaskID, model.PermissionEdit)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID, time.Since(start), serviceErr)
+		return serviceErr.ToGRPCStatus()
+	}
+	attachment.UploaderID = identity.Actor(ctx)
+
+	usage, err := s.attachmentRepo.Usage(ctx)
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, taskID, time.Since(start), serviceErr)
+		return serviceErr.ToGRPCStatus()
+	}
+	if usage >= s.attachmentConfig.Quota {
+		logger.LogTaskOperation(ctx, operation, taskID, time.Since(start), errors.ErrQuotaExceeded)
+		return errors.ErrQuotaExceeded.ToGRPCStatus()
+	}
+
+	file, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		logger.LogError(ctx, err, operation)
+		return errors.ErrInternalError.ToGRPCStatus()
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := s.receiveAttachment(stream, file, attachment, s.attachmentConfig.Quota-usage); err != nil {
+		var serviceErr *errors.ServiceError
+		if stderrors.As(err, &serviceErr) {
+			logger.LogTaskOperation(ctx, operation, taskID, time.Since(start), serviceErr)
+			return serviceErr.ToGRPCStatus()
+		}
+		return err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		logger.LogError(ctx, err, operation)
+		return errors.ErrInternalError.ToGRPCStatus()
+	}
+	if err := s.blobStore.Put(ctx, attachment.StorageKey, file, attachment.Size); err != nil {
+		logger.LogError(ctx, err, operation)
+		return errors.ErrBlobStoreUnavailable.ToGRPCStatus()
+	}
+
+	created, err := s.attachmentRepo.Create(ctx, attachment, s.attachmentConfig.Quota)
+	duration := time.Since(start)
+
+	if err != nil {
+		if err := s.blobStore.Delete(context.WithoutCancel(ctx), attachment.StorageKey); err != nil {
+			logger.LogError(ctx, err, operation)
+		}
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, taskID, duration, serviceErr)
+		return serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, taskID, duration, nil)
+
+	return stream.SendAndClose(&pb.AttachmentResponse{
+		Attachment: model.AttachmentToProto(created),
+	})
+}
+
+// receiveAttachment writes the chunks of an upload to file and records
+// their size and checksum on attachment. It fails with a service error once
+// the contents pass the size limit or the remaining quota.
+func (s *taskService) receiveAttachment(stream pb.TaskService_UploadAttachmentServer, file *os.File, attachment *model.Attachment, remaining int64) error {
+	hash := sha256.New()
+	w := io.MultiWriter(file, hash)
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		chunk, ok := req.Payload.(*pb.UploadAttachmentRequest_Chunk)
+		if !ok {
+			return errors.ErrInvalidUpload
+		}
+
+		attachment.Size += int64(len(chunk.Chunk))
+		switch {
+		case attachment.Size > s.attachmentConfig.MaxSize:
+			return errors.ErrAttachmentTooLarge
+		case attachment.Size > remaining:
+			return errors.ErrQuotaExceeded
+		}
+
+		if _, err := w.Write(chunk.Chunk); err != nil {
+			return err
+		}
+	}
+
+	attachment.Checksum = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+// DownloadAttachment sends the attachment followed by its contents in
+// chunks of the configured size.
+func (s *taskService) DownloadAttachment(req *pb.DownloadAttachmentRequest, stream pb.TaskService_DownloadAttachmentServer) error {
+	ctx := stream.Context()
+	start := time.Now()
+	operation := "DownloadAttachment"
+
+	taskID, attachmentID, err := model.AttachmentIDsFromProto(req.GetTaskId(), req.GetAttachmentId())
+	if err != nil {
+		serviceErr := attachmentRequestError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return serviceErr.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, taskID, model.PermissionRead)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return serviceErr.ToGRPCStatus()
+	}
+
+	attachment, err := s.attachmentRepo.Get(ctx, taskID, attachmentID)
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return serviceErr.ToGRPCStatus()
+	}
+
+	body, err := s.blobStore.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		logger.LogError(ctx, err, operation)
+		return errors.ErrBlobStoreUnavailable.ToGRPCStatus()
+	}
+	defer body.Close()
+
+	err = stream.Send(&pb.DownloadAttachmentResponse{
+		Payload: &pb.DownloadAttachmentResponse_Attachment{Attachment: model.AttachmentToProto(attachment)},
+	})
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, s.attachmentConfig.ChunkSize)
+	for {
+		n, err := io.ReadFull(body, buf)
+		if n > 0 {
+			chunk := &pb.DownloadAttachmentResponse{
+				Payload: &pb.DownloadAttachmentResponse_Chunk{Chunk: buf[:n]},
+			}
+			if err := stream.Send(chunk); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			logger.LogError(ctx, err, operation)
+			return errors.ErrBlobStoreUnavailable.ToGRPCStatus()
+		}
+	}
+	duration := time.Since(start)
+
+	logger.LogTaskOperation(ctx, operation, taskID.String(), duration, nil)
+	return nil
+}
+
+func (s *taskService) ListAttachments(ctx context.Context, req *pb.ListAttachmentsRequest) (*pb.ListAttachmentsResponse, error) {
+	start := time.Now()
+	operation := "ListAttachments"
+
+	taskID, err := uuid.Parse(req.GetTaskId())
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTaskId, operation)
+		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, taskID, model.PermissionRead)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	attachments, err := s.attachmentRepo.List(ctx, taskID)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, taskID.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, taskID.String(), duration, nil)
+
+	return &pb.ListAttachmentsResponse{
+		Attachments: model.AttachmentsToProto(attachments),
+	}, nil
+}
+
+// DeleteAttachment removes the attachment at once; its contents are left
+// for the purger to delete from the blob store.
+func (s *taskService) DeleteAttachment(ctx context.Context, req *pb.DeleteAttachmentRequest) (*pb.DeleteAttachmentResponse, error) {
+	start := time.Now()
+	operation := "DeleteAttachment"
+
+	taskID, attachmentID, err := model.AttachmentIDsFromProto(req.GetTaskId(), req.GetAttachmentId())
+	if err != nil {
+		serviceErr := attachmentRequestError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, taskID, model.PermissionEdit)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	err = s.attachmentRepo.Delete(ctx, taskID, attachmentID)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, taskID.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, taskID.String(), duration, nil)
+
+	return &pb.DeleteAttachmentResponse{
+		Success: true,
+	}, nil
+}
+
+func attachmentRequestError(err error) *errors.ServiceError {
+	switch {
+	case stderrors.Is(err, model.ErrInvalidAttachmentName):
+		return errors.ErrInvalidAttachmentName
+	case stderrors.Is(err, model.ErrInvalidAttachmentID):
+		return errors.ErrInvalidAttachmentId
+	default:
+		return errors.ErrInvalidTaskId
+	}
+}
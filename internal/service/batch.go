@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/internal/repository"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+	"github.com/google/uuid"
+)
+
+// batchRequest collects the items of a batch that passed validation together
+// with their positions in the request, and the errors of those that did not.
+type batchRequest struct {
+	size    int
+	atomic  bool
+	indexes []int
+	invalid map[int]*errors.ServiceError
+}
+
+func newBatchRequest(size int, mode pb.BatchMode) *batchRequest {
+	return &batchRequest{
+		size:    size,
+		atomic:  model.BatchAtomicFromProto(mode),
+		invalid: make(map[int]*errors.ServiceError),
+	}
+}
+
+func (s *taskService) BatchCreateTasks(ctx context.Context, req *pb.BatchCreateTasksRequest) (*pb.BatchTasksResponse, error) {
+	start := time.Now()
+	operation := "BatchCreateTasks"
+
+	if err := validateBatchSize(len(req.Tasks)); err != nil {
+		logger.LogError(ctx, err, operation)
+		return nil, err.ToGRPCStatus()
+	}
+
+	batch := newBatchRequest(len(req.Tasks), req.Mode)
+	tasks := make([]*model.Task, 0, len(req.Tasks))
+	schemas := make(map[uuid.UUID]model.CustomFieldSchema)
+	for i, item := range req.Tasks {
+		if item.Title == "" {
+			batch.invalid[i] = errors.ErrTitleNotSpecified
+			continue
+		}
+
+		id, err := model.CreateTaskIDFromProto(item)
+		if err != nil {
+			batch.invalid[i] = errors.ErrInvalidClientId
+			continue
+		}
+
+		if err := model.ValidateRecurrence(item.Recurrence); err != nil {
+			batch.invalid[i] = errors.ErrInvalidRecurrence
+			continue
+		}
+
+		tags, serviceErr := tagsFromRequest(item.Tags)
+		if serviceErr != nil {
+			batch.invalid[i] = serviceErr
+			continue
+		}
+
+		if !model.Priority(item.Priority).Valid() {
+			batch.invalid[i] = errors.ErrInvalidPriority
+			continue
+		}
+
+		listID, err := model.ListIDFromProto(item.ListId)
+		if err != nil {
+			batch.invalid[i] = errors.ErrInvalidListId
+			continue
+		}
+		parentID, err := model.ParentIDFromProto(item.ParentId)
+		if err != nil {
+			batch.invalid[i] = errors.ErrInvalidParentId
+			continue
+		}
+
+		customFields, err := model.CustomFieldValuesFromProto(item.CustomFields)
+		if err != nil {
+			batch.invalid[i] = errors.ErrInvalidCustomFieldValue
+			continue
+		}
+		if serviceErr := s.validateCustomFields(ctx, listID, customFields, schemas); serviceErr != nil {
+			batch.invalid[i] = serviceErr
+			continue
+		}
+
+		title, description := model.CreateTaskRequestFromProto(item)
+		task := model.NewTask(title, description)
+		task.CompletedFromItems = item.CompletedFromItems
+		task.DueAt, task.RemindAt = model.CreateTaskScheduleFromProto(item)
+		if id != uuid.Nil {
+			task.ID = id
+		}
+		task.SetRecurrence(item.Recurrence, task.CreatedAt)
+		if tags != nil {
+			task.Tags = tags
+		}
+		task.Priority = model.Priority(item.Priority)
+		task.ListID = listID
+		task.ParentID = parentID
+		task.CustomFields = customFields
+
+		tasks = append(tasks, task)
+		batch.indexes = append(batch.indexes, i)
+	}
+
+	return s.runBatch(ctx, operation, start, batch, func() ([]model.BatchResult, error) {
+		return s.taskRepo.BatchCreate(ctx, tasks, batch.atomic)
+	})
+}
+
+func (s *taskService) BatchUpdateTasks(ctx context.Context, req *pb.BatchUpdateTasksRequest) (*pb.BatchTasksResponse, error) {
+	start := time.Now()
+	operation := "BatchUpdateTasks"
+
+	if err := validateBatchSize(len(req.Tasks)); err != nil {
+		logger.LogError(ctx, err, operation)
+		return nil, err.ToGRPCStatus()
+	}
+
+	batch := newBatchRequest(len(req.Tasks), req.Mode)
+	patches := make([]model.TaskPatch, 0, len(req.Tasks))
+	for i, item := range req.Tasks {
+		id, err := uuid.Parse(item.Id)
+		if err != nil {
+			batch.invalid[i] = errors.ErrInvalidTaskId
+			continue
+		}
+		if item.Title != nil && *item.Title == "" {
+			batch.invalid[i] = errors.ErrTitleNotSpecified
+			continue
+		}
+		if item.Recurrence != nil && model.ValidateRecurrence(*item.Recurrence) != nil {
+			batch.invalid[i] = errors.ErrInvalidRecurrence
+			continue
+		}
+
+		update := model.UpdateTaskRequestFromProto(item)
+		if err := update.NormalizeTags(); err != nil {
+			batch.invalid[i] = errors.ErrInvalidTags
+			continue
+		}
+		if update.Priority != nil && !update.Priority.Valid() {
+			batch.invalid[i] = errors.ErrInvalidPriority
+			continue
+		}
+		if update.ListID, err = model.ListIDFromProto(item.ListId); err != nil {
+			batch.invalid[i] = errors.ErrInvalidListId
+			continue
+		}
+		if update.ParentID, err = model.ParentIDFromProto(item.ParentId); err != nil {
+			batch.invalid[i] = errors.ErrInvalidParentId
+			continue
+		}
+		if update.SetCustomFields, err = model.CustomFieldValuesFromProto(item.SetCustomFields); err != nil {
+			batch.invalid[i] = errors.ErrInvalidCustomFieldValue
+			continue
+		}
+
+		patches = append(patches, model.TaskPatch{
+			ID:              id,
+			Update:          update,
+			ExpectedVersion: item.ExpectedVersion,
+		})
+		batch.indexes = append(batch.indexes, i)
+	}
+
+	ids := make([]uuid.UUID, len(patches))
+	for j, patch := range patches {
+		ids[j] = patch.ID
+	}
+	keep, err := s.rejectForeign(ctx, batch, ids)
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+	kept := patches[:0]
+	for j, patch := range patches {
+		if keep[j] {
+			kept = append(kept, patch)
+		}
+	}
+	patches = s.rejectInvalidCustomFields(ctx, batch, kept)
+
+	return s.runBatch(ctx, operation, start, batch, func() ([]model.BatchResult, error) {
+		return s.taskRepo.BatchUpdate(ctx, patches, batch.atomic)
+	})
+}
+
+func (s *taskService) BatchDeleteTasks(ctx context.Context, req *pb.BatchDeleteTasksRequest) (*pb.BatchTasksResponse, error) {
+	start := time.Now()
+	operation := "BatchDeleteTasks"
+
+	if err := validateBatchSize(len(req.Tasks)); err != nil {
+		logger.LogError(ctx, err, operation)
+		return nil, err.ToGRPCStatus()
+	}
+
+	batch := newBatchRequest(len(req.Tasks), req.Mode)
+	deletions := make([]model.TaskDeletion, 0, len(req.Tasks))
+	for i, item := range req.Tasks {
+		id, err := model.DeleteTaskRequestFromProto(item)
+		if err != nil {
+			batch.invalid[i] = errors.ErrInvalidTaskId
+			continue
+		}
+
+		deletions = append(deletions, model.TaskDeletion{
+			ID:              id,
+			ExpectedVersion: item.ExpectedVersion,
+			Cascade:         item.Cascade,
+		})
+		batch.indexes = append(batch.indexes, i)
+	}
+
+	ids := make([]uuid.UUID, len(deletions))
+	for j, deletion := range deletions {
+		ids[j] = deletion.ID
+	}
+	keep, err := s.rejectForeign(ctx, batch, ids)
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+	kept := deletions[:0]
+	for j, deletion := range deletions {
+		if keep[j] {
+			kept = append(kept, deletion)
+		}
+	}
+	deletions = kept
+
+	return s.runBatch(ctx, operation, start, batch, func() ([]model.BatchResult, error) {
+		return s.taskRepo.BatchDelete(ctx, deletions, batch.atomic)
+	})
+}
+
+// rejectInvalidCustomFields marks patches that set custom fields the list
+// their task ends up in does not accept as invalid and returns the
+// remaining ones. The list is looked up for patches that leave it as it is.
+func (s *taskService) rejectInvalidCustomFields(ctx context.Context, batch *batchRequest, patches []model.TaskPatch) []model.TaskPatch {
+	schemas := make(map[uuid.UUID]model.CustomFieldSchema)
+	kept := patches[:0]
+	indexes := batch.indexes[:0]
+	for j, patch := range patches {
+		if serviceErr := s.validatePatchCustomFields(ctx, patch, schemas); serviceErr != nil {
+			batch.invalid[batch.indexes[j]] = serviceErr
+			continue
+		}
+		kept = append(kept, patch)
+		indexes = append(indexes, batch.indexes[j])
+	}
+	batch.indexes = indexes
+	return kept
+}
+
+func (s *taskService) validatePatchCustomFields(ctx context.Context, patch model.TaskPatch, schemas map[uuid.UUID]model.CustomFieldSchema) *errors.ServiceError {
+	update := patch.Update
+	if len(update.SetCustomFields) == 0 {
+		return nil
+	}
+
+	listID := update.ListID
+	switch {
+	case update.ClearListID:
+		listID = nil
+	case listID == nil:
+		task, err := s.taskRepo.GetByID(ctx, patch.ID)
+		if err != nil {
+			return taskRepositoryError(err)
+		}
+		listID = task.ListID
+	}
+	return s.validateCustomFields(ctx, listID, update.SetCustomFields, schemas)
+}
+
+// runBatch sends the valid items to the repository and merges the outcome
+// with the validation errors. An atomic batch with invalid items never
+// reaches the database.
+func (s *taskService) runBatch(ctx context.Context, operation string, start time.Time, batch *batchRequest, run func() ([]model.BatchResult, error)) (*pb.BatchTasksResponse, error) {
+	results := make([]*pb.BatchTaskResult, batch.size)
+	for i := range results {
+		results[i] = &pb.BatchTaskResult{Index: int32(i)}
+	}
+
+	for i, serviceErr := range batch.invalid {
+		setBatchError(results[i], serviceErr)
+	}
+
+	if len(batch.invalid) > 0 && batch.atomic {
+		for _, i := range batch.indexes {
+			setBatchError(results[i], errors.ErrBatchAborted)
+		}
+	} else if len(batch.indexes) > 0 {
+		repoResults, err := run()
+		if err != nil {
+			duration := time.Since(start)
+			serviceErr := errors.WrapRepositoryError(err)
+			logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+			return nil, serviceErr.ToGRPCStatus()
+		}
+
+		for j, result := range repoResults {
+			i := batch.indexes[j]
+			if result.Err != nil {
+				setBatchError(results[i], batchRepositoryError(result.Err))
+				continue
+			}
+			results[i].Task = model.TaskToProto(result.Task)
+		}
+	}
+
+	duration := time.Since(start)
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.BatchTasksResponse{
+		Results: results,
+	}, nil
+}
+
+func validateBatchSize(size int) *errors.ServiceError {
+	switch {
+	case size == 0:
+		return errors.ErrBatchEmpty
+	case size > model.MaxBatchSize:
+		return errors.ErrBatchTooLarge
+	default:
+		return nil
+	}
+}
+
+func setBatchError(result *pb.BatchTaskResult, serviceErr *errors.ServiceError) {
+	result.Task = nil
+	result.ErrorCode = int32(serviceErr.Code)
+	result.ErrorMessage = serviceErr.Message
+}
+
+func batchRepositoryError(err error) *errors.ServiceError {
+	if repository.IsBatchAbortedError(err) {
+		return errors.ErrBatchAborted
+	}
+	return taskRepositoryError(err)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/internal/repository"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+	"github.com/google/uuid"
+)
+
+func (s *taskService) AddChecklistItem(ctx context.Context, req *pb.AddChecklistItemRequest) (*pb.TaskResponse, error) {
+	start := time.Now()
+	operation := "AddChecklistItem"
+
+	taskID, err := uuid.Parse(req.TaskId)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTaskId, operation)
+		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		logger.LogError(ctx, errors.ErrItemTitleRequired, operation)
+		return nil, errors.ErrItemTitleRequired.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, taskID, model.PermissionEdit)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	task, err := s.taskRepo.AddItem(ctx, model.NewChecklistItem(taskID, title))
+	return s.checklistResponse(ctx, operation, taskID, start, task, err)
+}
+
+func (s *taskService) ReorderChecklistItems(ctx context.Context, req *pb.ReorderChecklistItemsRequest) (*pb.TaskResponse, error) {
+	start := time.Now()
+	operation := "ReorderChecklistItems"
+
+	taskID, err := uuid.Parse(req.TaskId)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTaskId, operation)
+		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
+	}
+
+	itemIDs := make([]uuid.UUID, len(req.ItemIds))
+	for i, raw := range req.ItemIds {
+		itemIDs[i], err = uuid.Parse(raw)
+		if err != nil {
+			logger.LogError(ctx, errors.ErrInvalidItemId, operation)
+			return nil, errors.ErrInvalidItemId.ToGRPCStatus()
+		}
+	}
+
+	ctx, serviceErr := s.authorize(ctx, taskID, model.PermissionEdit)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	task, err := s.taskRepo.ReorderItems(ctx, taskID, itemIDs)
+	return s.checklistResponse(ctx, operation, taskID, start, task, err)
+}
+
+func (s *taskService) ToggleChecklistItem(ctx context.Context, req *pb.ToggleChecklistItemRequest) (*pb.TaskResponse, error) {
+	start := time.Now()
+	operation := "ToggleChecklistItem"
+
+	taskID, err := uuid.Parse(req.TaskId)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTaskId, operation)
+		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
+	}
+
+	itemID, err := uuid.Parse(req.ItemId)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidItemId, operation)
+		return nil, errors.ErrInvalidItemId.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, taskID, model.PermissionEdit)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	task, err := s.taskRepo.ToggleItem(ctx, taskID, itemID, req.Completed)
+	return s.checklistResponse(ctx, operation, taskID, start, task, err)
+}
+
+func (s *taskService) DeleteChecklistItem(ctx context.Context, req *pb.DeleteChecklistItemRequest) (*pb.TaskResponse, error) {
+	start := time.Now()
+	operation := "DeleteChecklistItem"
+
+	taskID, err := uuid.Parse(req.TaskId)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTaskId, operation)
+		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
+	}
+
+	itemID, err := uuid.Parse(req.ItemId)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidItemId, operation)
+		return nil, errors.ErrInvalidItemId.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, taskID, model.PermissionEdit)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	task, err := s.taskRepo.DeleteItem(ctx, taskID, itemID)
+	return s.checklistResponse(ctx, operation, taskID, start, task, err)
+}
+
+func (s *taskService) checklistResponse(ctx context.Context, operation string, taskID uuid.UUID, start time.Time, task *model.Task, err error) (*pb.TaskResponse, error) {
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := checklistRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, taskID.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, taskID.String(), duration, nil)
+
+	return &pb.TaskResponse{
+		Task: model.TaskToProto(task),
+	}, nil
+}
+
+func checklistRepositoryError(err error) *errors.ServiceError {
+	switch {
+	case repository.IsItemNotFoundError(err):
+		return errors.ErrItemNotFound
+	case repository.IsInvalidItemOrderError(err):
+		return errors.ErrInvalidItemOrder
+	default:
+		return taskRepositoryError(err)
+	}
+}
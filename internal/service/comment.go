@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+)
+
+func (s *taskService) AddComment(ctx context.Context, req *pb.AddCommentRequest) (*pb.CommentResponse, error) {
+	start := time.Now()
+	operation := "AddComment"
+
+	taskID, body, err := model.AddCommentRequestFromProto(req)
+	if err != nil {
+		serviceErr := commentRequestError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, taskID, model.PermissionRead)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	comment, err := s.commentRepo.Create(ctx, model.NewComment(taskID, identity.Actor(ctx), body))
+	return commentResponse(ctx, operation, taskID.String(), start, comment, err)
+}
+
+// EditComment replaces the body of one of the caller's own comments.
+func (s *taskService) EditComment(ctx context.Context, req *pb.EditCommentRequest) (*pb.CommentResponse, error) {
+	start := time.Now()
+	operation := "EditComment"
+
+	taskID, commentID, body, err := model.EditCommentRequestFromProto(req)
+	if err != nil {
+		serviceErr := commentRequestError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, taskID, model.PermissionRead)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	comment, err := s.commentRepo.Get(ctx, taskID, commentID)
+	if err == nil {
+		if comment.AuthorID != identity.Actor(ctx) {
+			logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), errors.ErrPermissionDenied)
+			return nil, errors.ErrPermissionDenied.ToGRPCStatus()
+		}
+		comment.Body = body
+		comment, err = s.commentRepo.Update(ctx, comment)
+	}
+	return commentResponse(ctx, operation, taskID.String(), start, comment, err)
+}
+
+// DeleteComment removes a comment of the caller's, or any comment on a task
+// the caller owns.
+func (s *taskService) DeleteComment(ctx context.Context, req *pb.DeleteCommentRequest) (*pb.DeleteCommentResponse, error) {
+	start := time.Now()
+	operation := "DeleteComment"
+
+	taskID, commentID, err := model.CommentIDsFromProto(req.GetTaskId(), req.GetCommentId())
+	if err != nil {
+		serviceErr := commentRequestError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, taskID, model.PermissionRead)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	comment, err := s.commentRepo.Get(ctx, taskID, commentID)
+	if err == nil {
+		actor := identity.Actor(ctx)
+		owner, _ := identity.FromContext(ctx)
+		if comment.AuthorID != actor && owner.UserID != actor {
+			logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), errors.ErrPermissionDenied)
+			return nil, errors.ErrPermissionDenied.ToGRPCStatus()
+		}
+		err = s.commentRepo.Delete(ctx, taskID, commentID)
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, taskID.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, taskID.String(), duration, nil)
+
+	return &pb.DeleteCommentResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *taskService) ListComments(ctx context.Context, req *pb.ListCommentsRequest) (*pb.ListCommentsResponse, error) {
+	start := time.Now()
+	operation := "ListComments"
+
+	if req.PageSize < 0 {
+		logger.LogError(ctx, errors.ErrInvalidPageSize, operation)
+		return nil, errors.ErrInvalidPageSize.ToGRPCStatus()
+	}
+
+	taskID, limit, cursor, err := model.ListCommentsRequestFromProto(req)
+	if err != nil {
+		serviceErr := commentRequestError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, taskID, model.PermissionRead)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, taskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	comments, next, err := s.commentRepo.List(ctx, taskID, limit, cursor)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, taskID.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, taskID.String(), duration, nil)
+
+	return &pb.ListCommentsResponse{
+		Comments:      model.CommentsToProto(comments),
+		NextPageToken: next.Encode(),
+	}, nil
+}
+
+func commentResponse(ctx context.Context, operation, taskID string, start time.Time, comment *model.Comment, err error) (*pb.CommentResponse, error) {
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, taskID, duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, taskID, duration, nil)
+
+	return &pb.CommentResponse{
+		Comment: model.CommentToProto(comment),
+	}, nil
+}
+
+func commentRequestError(err error) *errors.ServiceError {
+	switch {
+	case stderrors.Is(err, model.ErrInvalidComment):
+		return errors.ErrInvalidComment
+	case stderrors.Is(err, model.ErrInvalidCommentID):
+		return errors.ErrInvalidCommentId
+	case stderrors.Is(err, model.ErrInvalidPageToken):
+		return errors.ErrInvalidPageToken
+	default:
+		return errors.ErrInvalidTaskId
+	}
+}
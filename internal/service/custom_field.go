@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+	"github.com/google/uuid"
+)
+
+func (s *taskService) CreateCustomField(ctx context.Context, req *pb.CreateCustomFieldRequest) (*pb.CustomFieldResponse, error) {
+	start := time.Now()
+	operation := "CreateCustomField"
+
+	field, err := model.CreateCustomFieldRequestFromProto(req)
+	if err != nil {
+		serviceErr := customFieldRequestError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	created, err := s.fieldRepo.Create(ctx, field, model.MaxCustomFieldsPerList)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.CustomFieldResponse{
+		Field: model.CustomFieldToProto(created),
+	}, nil
+}
+
+func (s *taskService) ListCustomFields(ctx context.Context, req *pb.ListCustomFieldsRequest) (*pb.ListCustomFieldsResponse, error) {
+	start := time.Now()
+	operation := "ListCustomFields"
+
+	listID, err := uuid.Parse(req.GetListId())
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidListId, operation)
+		return nil, errors.ErrInvalidListId.ToGRPCStatus()
+	}
+
+	fields, err := s.fieldRepo.List(ctx, listID)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.ListCustomFieldsResponse{
+		Fields: model.CustomFieldsToProto(fields),
+	}, nil
+}
+
+// DeleteCustomField removes the field together with its values, so that a
+// field defined again under the same key starts out empty.
+func (s *taskService) DeleteCustomField(ctx context.Context, req *pb.DeleteCustomFieldRequest) (*pb.DeleteCustomFieldResponse, error) {
+	start := time.Now()
+	operation := "DeleteCustomField"
+
+	listID, key, err := model.DeleteCustomFieldRequestFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidListId, operation)
+		return nil, errors.ErrInvalidListId.ToGRPCStatus()
+	}
+
+	err = s.taskRepo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.fieldRepo.Delete(ctx, listID, key); err != nil {
+			return err
+		}
+		return s.taskRepo.ClearCustomField(ctx, listID, key)
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.DeleteCustomFieldResponse{
+		Success: true,
+	}, nil
+}
+
+// validateCustomFields checks values against the fields of the list a task
+// ends up in. Schemas caches the fields of the lists already loaded, so
+// that a batch loads each list once.
+func (s *taskService) validateCustomFields(ctx context.Context, listID *uuid.UUID, values map[string]interface{}, schemas map[uuid.UUID]model.CustomFieldSchema) *errors.ServiceError {
+	if len(values) == 0 {
+		return nil
+	}
+	if listID == nil {
+		return errors.ErrInvalidCustomFieldValue
+	}
+
+	schema, ok := schemas[*listID]
+	if !ok {
+		fields, err := s.fieldRepo.List(ctx, *listID)
+		if err != nil {
+			return errors.WrapRepositoryError(err)
+		}
+		schema = model.NewCustomFieldSchema(fields)
+		schemas[*listID] = schema
+	}
+
+	if err := schema.Validate(values); err != nil {
+		return errors.ErrInvalidCustomFieldValue
+	}
+	return nil
+}
+
+// validateCustomFieldFilters checks the custom field filters of a listing
+// against the fields of the list it is limited to.
+func (s *taskService) validateCustomFieldFilters(ctx context.Context, filter model.TaskFilter) *errors.ServiceError {
+	if len(filter.CustomFields) == 0 {
+		return nil
+	}
+
+	fields, err := s.fieldRepo.List(ctx, *filter.ListID)
+	if err != nil {
+		return errors.WrapRepositoryError(err)
+	}
+
+	schema := model.NewCustomFieldSchema(fields)
+	for _, customField := range filter.CustomFields {
+		if err := schema.ValidateFilter(customField); err != nil {
+			return errors.ErrInvalidCustomFieldFilter
+		}
+	}
+	return nil
+}
+
+func customFieldRequestError(err error) *errors.ServiceError {
+	if stderrors.Is(err, model.ErrInvalidListID) {
+		return errors.ErrInvalidListId
+	}
+	return errors.ErrInvalidCustomField
+}
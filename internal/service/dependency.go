@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/internal/repository"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+	"github.com/google/uuid"
+)
+
+func (s *taskService) AddDependency(ctx context.Context, req *pb.DependencyRequest) (*pb.DependencyResponse, error) {
+	return s.changeDependency(ctx, "AddDependency", req, s.depRepo.Add)
+}
+
+func (s *taskService) RemoveDependency(ctx context.Context, req *pb.DependencyRequest) (*pb.DependencyResponse, error) {
+	return s.changeDependency(ctx, "RemoveDependency", req, s.depRepo.Remove)
+}
+
+func (s *taskService) changeDependency(ctx context.Context, operation string, req *pb.DependencyRequest, change func(context.Context, model.Dependency) error) (*pb.DependencyResponse, error) {
+	start := time.Now()
+
+	dependency, err := model.DependencyRequestFromProto(req)
+	if err != nil {
+		serviceErr := errors.ErrInvalidTaskId
+		if stderrors.Is(err, model.ErrInvalidDependency) {
+			serviceErr = errors.ErrInvalidDependency
+		}
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	// Both tasks belong to one owner, in whose scope the change is made.
+	_, serviceErr := s.authorize(ctx, dependency.BlockerID, model.PermissionRead)
+	if serviceErr == nil {
+		ctx, serviceErr = s.authorize(ctx, dependency.TaskID, model.PermissionEdit)
+	}
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, dependency.TaskID.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	err = change(ctx, dependency)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, dependency.TaskID.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, dependency.TaskID.String(), duration, nil)
+
+	return &pb.DependencyResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *taskService) GetTaskGraph(ctx context.Context, req *pb.GetTaskGraphRequest) (*pb.GetTaskGraphResponse, error) {
+	start := time.Now()
+	operation := "GetTaskGraph"
+
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTaskId, operation)
+		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, id, model.PermissionRead)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, id.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	graph, err := s.depRepo.Graph(ctx, id, model.MaxGraphTasks)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, id.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, id.String(), duration, nil)
+
+	return model.TaskGraphToProto(graph), nil
+}
+
+// checkBlockers refuses to complete tasks while one of them has a blocker
+// that is still open. It belongs in the transaction that completes them,
+// which keeps the blockers from being reopened until it commits.
+func (s *taskService) checkBlockers(ctx context.Context, ids ...uuid.UUID) error {
+	blocked, err := s.depRepo.Blocked(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if len(blocked) > 0 {
+		return repository.ErrTaskBlocked
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+)
+
+func (s *taskService) GetTaskHistory(ctx context.Context, req *pb.GetTaskHistoryRequest) (*pb.GetTaskHistoryResponse, error) {
+	start := time.Now()
+	operation := "GetTaskHistory"
+
+	if req.PageSize < 0 {
+		logger.LogError(ctx, errors.ErrInvalidPageSize, operation)
+		return nil, errors.ErrInvalidPageSize.ToGRPCStatus()
+	}
+
+	id, opts, err := model.GetTaskHistoryRequestFromProto(req)
+	if err != nil {
+		serviceErr := errors.ErrInvalidTaskId
+		if stderrors.Is(err, model.ErrInvalidPageToken) {
+			serviceErr = errors.ErrInvalidPageToken
+		}
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	// The history of a purged task outlives it and stays with its owner.
+	ctx, serviceErr := s.authorize(ctx, id, model.PermissionRead)
+	if serviceErr != nil && serviceErr != errors.ErrTaskNotFound {
+		logger.LogTaskOperation(ctx, operation, id.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	page, err := s.taskRepo.ListHistory(ctx, id, opts)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, id.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, id.String(), duration, nil)
+
+	return &pb.GetTaskHistoryResponse{
+		Entries:       model.TaskHistoryToProto(page.Entries),
+		NextPageToken: page.NextCursor.Encode(),
+	}, nil
+}
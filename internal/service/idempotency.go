@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"strings"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	IdempotencyKeyMetadataKey = "idempotency-key"
+	idempotencyTTL            = 24 * time.Hour
+)
+
+// idempotencyKey prefers the request field over the metadata header.
+func idempotencyKey(ctx context.Context, req *pb.CreateTaskRequest) (string, *errors.ServiceError) {
+	key := strings.TrimSpace(req.IdempotencyKey)
+	if key == "" {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(IdempotencyKeyMetadataKey); len(values) > 0 {
+				key = strings.TrimSpace(values[0])
+			}
+		}
+	}
+
+	if len(key) > model.MaxIdempotencyKeyLength {
+		return "", errors.ErrInvalidIdempotencyKey
+	}
+	return key, nil
+}
+
+// requestHash fingerprints a request so that a reused key can be told apart
+// from a retry. The key itself is not part of the fingerprint.
+func requestHash(req *pb.CreateTaskRequest) (string, error) {
+	clone := proto.Clone(req).(*pb.CreateTaskRequest)
+	clone.IdempotencyKey = ""
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(clone)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// createTaskIdempotent reserves the key, creates the task and stores the
+// response in one transaction, or replays the response stored for the key.
+func (s *taskService) createTaskIdempotent(ctx context.Context, req *pb.CreateTaskRequest, key string, task *model.Task, start time.Time) (*pb.TaskResponse, error) {
+	operation := "CreateTask"
+
+	hash, err := requestHash(req)
+	if err != nil {
+		logger.LogError(ctx, err, operation)
+		return nil, errors.ErrInternalError.ToGRPCStatus()
+	}
+
+	var (
+		resp     *pb.TaskResponse
+		replayed bool
+	)
+	err = s.taskRepo.WithTx(ctx, func(ctx context.Context) error {
+		existing, err := s.idempotencyRepo.Reserve(ctx, model.IdempotencyRecord{
+			Key:         key,
+			Operation:   operation,
+			RequestHash: hash,
+			ExpiresAt:   time.Now().Add(idempotencyTTL),
+		})
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			if existing.Operation != operation || existing.RequestHash != hash {
+				return errors.ErrIdempotencyKeyReused
+			}
+			resp = &pb.TaskResponse{}
+			replayed = true
+			return proto.Unmarshal(existing.Response, resp)
+		}
+
+		savedTask, err := s.taskRepo.Create(ctx, task)
+		if err != nil {
+			return err
+		}
+
+		resp = &pb.TaskResponse{
+			Task: model.TaskToProto(savedTask),
+		}
+		data, err := proto.Marshal(resp)
+		if err != nil {
+			return err
+		}
+		return s.idempotencyRepo.Complete(ctx, key, data)
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		var serviceErr *errors.ServiceError
+		if !stderrors.As(err, &serviceErr) {
+			serviceErr = errors.WrapRepositoryError(err)
+		}
+		logger.LogTaskOperation(ctx, operation, task.ID.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	if replayed {
+		logger.LogTaskOperation(ctx, operation+"Replay", resp.GetTask().GetId(), duration, nil)
+	} else {
+		logger.LogTaskOperation(ctx, operation, resp.GetTask().GetId(), duration, nil)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"sort"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/internal/repository"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+)
+
+// completeRecurring saves a recurring task that is being completed together
+// with its next occurrence. If the occurrence already exists because the
+// task was completed before, it is not created again.
+func (s *taskService) completeRecurring(ctx context.Context, task *model.Task, expectedVersion *int64) (*model.Task, *model.Task, error) {
+	var updatedTask, nextTask *model.Task
+
+	err := s.taskRepo.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		updatedTask, err = s.taskRepo.Update(ctx, task, expectedVersion)
+		if err != nil {
+			return err
+		}
+
+		next, err := updatedTask.NextOccurrence(time.Now())
+		if err != nil || next == nil {
+			return err
+		}
+
+		nextTask, err = s.taskRepo.Create(ctx, next)
+		if stderrors.Is(err, repository.ErrOccurrenceExists) {
+			nextTask = nil
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return updatedTask, nextTask, nil
+}
+
+func (s *taskService) ListOccurrences(ctx context.Context, req *pb.ListOccurrencesRequest) (*pb.ListOccurrencesResponse, error) {
+	start := time.Now()
+	operation := "ListOccurrences"
+
+	if req.PageSize < 0 {
+		logger.LogError(ctx, errors.ErrInvalidPageSize, operation)
+		return nil, errors.ErrInvalidPageSize.ToGRPCStatus()
+	}
+
+	from, until, limit, err := model.ListOccurrencesRequestFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidWindow, operation)
+		return nil, errors.ErrInvalidWindow.ToGRPCStatus()
+	}
+
+	tasks, err := s.taskRepo.ListRecurring(ctx, from, until, limit)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	occurrences := []*model.Occurrence{}
+	for _, task := range tasks {
+		if task.DueAt != nil && !task.DueAt.Before(from) && task.DueAt.Before(until) {
+			occurrences = append(occurrences, &model.Occurrence{Task: task, OccursAt: *task.DueAt, Materialized: true})
+		}
+		for _, at := range task.ProjectedOccurrences(from, until, limit) {
+			occurrences = append(occurrences, &model.Occurrence{Task: task, OccursAt: at})
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].OccursAt.Before(occurrences[j].OccursAt)
+	})
+	if len(occurrences) > limit {
+		occurrences = occurrences[:limit]
+	}
+
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.ListOccurrencesResponse{
+		Occurrences: model.OccurrencesToProto(occurrences),
+	}, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+	"github.com/google/uuid"
+)
+
+func (s *taskService) GetTaskTree(ctx context.Context, req *pb.GetTaskTreeRequest) (*pb.GetTaskTreeResponse, error) {
+	start := time.Now()
+	operation := "GetTaskTree"
+
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTaskId, operation)
+		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, id, model.PermissionRead)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, id.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	tree, err := s.taskRepo.Tree(ctx, id, model.MaxTreeTasks)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := taskRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, id.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, id.String(), duration, nil)
+
+	return model.TaskTreeToProto(tree), nil
+}
+
+// completeCascading saves a task that is being completed and completes its
+// subtasks in the same transaction. A recurring task still spawns its next
+// occurrence.
+func (s *taskService) completeCascading(ctx context.Context, task *model.Task, expectedVersion *int64) (*model.Task, *model.Task, error) {
+	var updatedTask, nextTask *model.Task
+
+	err := s.taskRepo.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if task.Recurrence != "" {
+			updatedTask, nextTask, err = s.completeRecurring(ctx, task, expectedVersion)
+		} else {
+			updatedTask, err = s.taskRepo.Update(ctx, task, expectedVersion)
+		}
+		if err != nil {
+			return err
+		}
+		return s.taskRepo.CompleteSubtasks(ctx, task.ID)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return updatedTask, nextTask, nil
+}
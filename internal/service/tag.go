@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+)
+
+func (s *taskService) ListTags(ctx context.Context, req *pb.ListTagsRequest) (*pb.ListTagsResponse, error) {
+	start := time.Now()
+	operation := "ListTags"
+
+	counts, err := s.taskRepo.ListTags(ctx)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.ListTagsResponse{
+		Tags: model.TagCountsToProto(counts),
+	}, nil
+}
+
+// tagsFromRequest normalizes the tags of a new task.
+func tagsFromRequest(tags []string) ([]string, *errors.ServiceError) {
+	normalized, err := model.NormalizeTags(tags)
+	if err != nil || len(normalized) > model.MaxTagsPerTask {
+		return nil, errors.ErrInvalidTags
+	}
+	return normalized, nil
+}
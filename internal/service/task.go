@@ -2,11 +2,16 @@ package service
 
 import (
 	"context"
+	stderrors "errors"
+	"strings"
 	"time"
 
+	"github.com/Raisondetr3/checklist-db-service/internal/blob"
+	"github.com/Raisondetr3/checklist-db-service/internal/config"
 	"github.com/Raisondetr3/checklist-db-service/internal/errors"
 	"github.com/Raisondetr3/checklist-db-service/internal/model"
 	"github.com/Raisondetr3/checklist-db-service/internal/repository"
+	"github.com/Raisondetr3/checklist-db-service/internal/watch"
 	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
 	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
 	"github.com/google/uuid"
@@ -18,15 +23,95 @@ type TaskService interface {
 	UpdateTask(ctx context.Context, req *pb.UpdateTaskRequest) (*pb.TaskResponse, error)
 	DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest) (*pb.DeleteTaskResponse, error)
 	ListTasks(ctx context.Context, req *pb.ListTasksRequest) (*pb.ListTasksResponse, error)
+	RestoreTask(ctx context.Context, req *pb.RestoreTaskRequest) (*pb.TaskResponse, error)
+	PurgeTask(ctx context.Context, req *pb.PurgeTaskRequest) (*pb.PurgeTaskResponse, error)
+	SearchTasks(ctx context.Context, req *pb.SearchTasksRequest) (*pb.SearchTasksResponse, error)
+	GetTaskHistory(ctx context.Context, req *pb.GetTaskHistoryRequest) (*pb.GetTaskHistoryResponse, error)
+	ListOccurrences(ctx context.Context, req *pb.ListOccurrencesRequest) (*pb.ListOccurrencesResponse, error)
+	ListTags(ctx context.Context, req *pb.ListTagsRequest) (*pb.ListTagsResponse, error)
+	MoveTask(ctx context.Context, req *pb.MoveTaskRequest) (*pb.TaskResponse, error)
+
+	ShareTask(ctx context.Context, req *pb.ShareTaskRequest) (*pb.TaskAccessResponse, error)
+	UnshareTask(ctx context.Context, req *pb.UnshareTaskRequest) (*pb.TaskAccessResponse, error)
+	AssignTask(ctx context.Context, req *pb.AssignTaskRequest) (*pb.TaskAccessResponse, error)
+	UnassignTask(ctx context.Context, req *pb.AssignTaskRequest) (*pb.TaskAccessResponse, error)
+	GetTaskAccess(ctx context.Context, req *pb.GetTaskAccessRequest) (*pb.TaskAccessResponse, error)
+
+	AddComment(ctx context.Context, req *pb.AddCommentRequest) (*pb.CommentResponse, error)
+	EditComment(ctx context.Context, req *pb.EditCommentRequest) (*pb.CommentResponse, error)
+	DeleteComment(ctx context.Context, req *pb.DeleteCommentRequest) (*pb.DeleteCommentResponse, error)
+	ListComments(ctx context.Context, req *pb.ListCommentsRequest) (*pb.ListCommentsResponse, error)
+
+	UploadAttachment(stream pb.TaskService_UploadAttachmentServer) error
+	DownloadAttachment(req *pb.DownloadAttachmentRequest, stream pb.TaskService_DownloadAttachmentServer) error
+	ListAttachments(ctx context.Context, req *pb.ListAttachmentsRequest) (*pb.ListAttachmentsResponse, error)
+	DeleteAttachment(ctx context.Context, req *pb.DeleteAttachmentRequest) (*pb.DeleteAttachmentResponse, error)
+
+	AddDependency(ctx context.Context, req *pb.DependencyRequest) (*pb.DependencyResponse, error)
+	RemoveDependency(ctx context.Context, req *pb.DependencyRequest) (*pb.DependencyResponse, error)
+	GetTaskGraph(ctx context.Context, req *pb.GetTaskGraphRequest) (*pb.GetTaskGraphResponse, error)
+	GetTaskTree(ctx context.Context, req *pb.GetTaskTreeRequest) (*pb.GetTaskTreeResponse, error)
+
+	CreateTaskList(ctx context.Context, req *pb.CreateTaskListRequest) (*pb.TaskListResponse, error)
+	GetTaskList(ctx context.Context, req *pb.GetTaskListRequest) (*pb.TaskListResponse, error)
+	UpdateTaskList(ctx context.Context, req *pb.UpdateTaskListRequest) (*pb.TaskListResponse, error)
+	DeleteTaskList(ctx context.Context, req *pb.DeleteTaskListRequest) (*pb.DeleteTaskListResponse, error)
+	ListTaskLists(ctx context.Context, req *pb.ListTaskListsRequest) (*pb.ListTaskListsResponse, error)
+
+	CreateCustomField(ctx context.Context, req *pb.CreateCustomFieldRequest) (*pb.CustomFieldResponse, error)
+	ListCustomFields(ctx context.Context, req *pb.ListCustomFieldsRequest) (*pb.ListCustomFieldsResponse, error)
+	DeleteCustomField(ctx context.Context, req *pb.DeleteCustomFieldRequest) (*pb.DeleteCustomFieldResponse, error)
+
+	CreateTemplate(ctx context.Context, req *pb.CreateTemplateRequest) (*pb.TemplateResponse, error)
+	GetTemplate(ctx context.Context, req *pb.GetTemplateRequest) (*pb.TemplateResponse, error)
+	UpdateTemplate(ctx context.Context, req *pb.UpdateTemplateRequest) (*pb.TemplateResponse, error)
+	DeleteTemplate(ctx context.Context, req *pb.DeleteTemplateRequest) (*pb.DeleteTemplateResponse, error)
+	ListTemplates(ctx context.Context, req *pb.ListTemplatesRequest) (*pb.ListTemplatesResponse, error)
+	InstantiateTemplate(ctx context.Context, req *pb.InstantiateTemplateRequest) (*pb.InstantiateTemplateResponse, error)
+
+	BatchCreateTasks(ctx context.Context, req *pb.BatchCreateTasksRequest) (*pb.BatchTasksResponse, error)
+	BatchUpdateTasks(ctx context.Context, req *pb.BatchUpdateTasksRequest) (*pb.BatchTasksResponse, error)
+	BatchDeleteTasks(ctx context.Context, req *pb.BatchDeleteTasksRequest) (*pb.BatchTasksResponse, error)
+
+	AddChecklistItem(ctx context.Context, req *pb.AddChecklistItemRequest) (*pb.TaskResponse, error)
+	ReorderChecklistItems(ctx context.Context, req *pb.ReorderChecklistItemsRequest) (*pb.TaskResponse, error)
+	ToggleChecklistItem(ctx context.Context, req *pb.ToggleChecklistItemRequest) (*pb.TaskResponse, error)
+	DeleteChecklistItem(ctx context.Context, req *pb.DeleteChecklistItemRequest) (*pb.TaskResponse, error)
+
+	WatchTasks(req *pb.WatchTasksRequest, stream pb.TaskService_WatchTasksServer) error
 }
 
 type taskService struct {
-	taskRepo repository.TaskRepository
+	taskRepo         repository.TaskRepository
+	listRepo         repository.TaskListRepository
+	fieldRepo        repository.CustomFieldRepository
+	templateRepo     repository.TemplateRepository
+	depRepo          repository.DependencyRepository
+	accessRepo       repository.AccessRepository
+	commentRepo      repository.CommentRepository
+	attachmentRepo   repository.AttachmentRepository
+	eventRepo        repository.TaskEventRepository
+	idempotencyRepo  repository.IdempotencyRepository
+	blobStore        blob.BlobStore
+	attachmentConfig config.AttachmentConfig
+	watchHub         *watch.Hub
 }
 
-func NewTaskService(taskRepo repository.TaskRepository) TaskService {
+func NewTaskService(taskRepo repository.TaskRepository, listRepo repository.TaskListRepository, fieldRepo repository.CustomFieldRepository, templateRepo repository.TemplateRepository, depRepo repository.DependencyRepository, accessRepo repository.AccessRepository, commentRepo repository.CommentRepository, attachmentRepo repository.AttachmentRepository, eventRepo repository.TaskEventRepository, idempotencyRepo repository.IdempotencyRepository, blobStore blob.BlobStore, attachmentConfig config.AttachmentConfig, watchHub *watch.Hub) TaskService {
 	return &taskService{
-		taskRepo: taskRepo,
+		taskRepo:         taskRepo,
+		listRepo:         listRepo,
+		fieldRepo:        fieldRepo,
+		templateRepo:     templateRepo,
+		depRepo:          depRepo,
+		accessRepo:       accessRepo,
+		commentRepo:      commentRepo,
+		attachmentRepo:   attachmentRepo,
+		eventRepo:        eventRepo,
+		idempotencyRepo:  idempotencyRepo,
+		blobStore:        blobStore,
+		attachmentConfig: attachmentConfig,
+		watchHub:         watchHub,
 	}
 }
 
@@ -39,8 +124,75 @@ func (s *taskService) CreateTask(ctx context.Context, req *pb.CreateTaskRequest)
 		return nil, errors.ErrTitleNotSpecified.ToGRPCStatus()
 	}
 
+	key, serviceErr := idempotencyKey(ctx, req)
+	if serviceErr != nil {
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	id, err := model.CreateTaskIDFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidClientId, operation)
+		return nil, errors.ErrInvalidClientId.ToGRPCStatus()
+	}
+
+	if err := model.ValidateRecurrence(req.Recurrence); err != nil {
+		logger.LogError(ctx, errors.ErrInvalidRecurrence, operation)
+		return nil, errors.ErrInvalidRecurrence.ToGRPCStatus()
+	}
+
+	tags, serviceErr := tagsFromRequest(req.Tags)
+	if serviceErr != nil {
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	if !model.Priority(req.Priority).Valid() {
+		logger.LogError(ctx, errors.ErrInvalidPriority, operation)
+		return nil, errors.ErrInvalidPriority.ToGRPCStatus()
+	}
+
+	listID, err := model.ListIDFromProto(req.ListId)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidListId, operation)
+		return nil, errors.ErrInvalidListId.ToGRPCStatus()
+	}
+	parentID, err := model.ParentIDFromProto(req.ParentId)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidParentId, operation)
+		return nil, errors.ErrInvalidParentId.ToGRPCStatus()
+	}
+
+	customFields, err := model.CustomFieldValuesFromProto(req.CustomFields)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidCustomFieldValue, operation)
+		return nil, errors.ErrInvalidCustomFieldValue.ToGRPCStatus()
+	}
+	schemas := make(map[uuid.UUID]model.CustomFieldSchema)
+	if serviceErr := s.validateCustomFields(ctx, listID, customFields, schemas); serviceErr != nil {
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
 	title, description := model.CreateTaskRequestFromProto(req)
 	task := model.NewTask(title, description)
+	task.CompletedFromItems = req.CompletedFromItems
+	task.DueAt, task.RemindAt = model.CreateTaskScheduleFromProto(req)
+	if id != uuid.Nil {
+		task.ID = id
+	}
+	task.SetRecurrence(req.Recurrence, task.CreatedAt)
+	if tags != nil {
+		task.Tags = tags
+	}
+	task.Priority = model.Priority(req.Priority)
+	task.ListID = listID
+	task.ParentID = parentID
+	task.CustomFields = customFields
+
+	if key != "" {
+		return s.createTaskIdempotent(ctx, req, key, task, start)
+	}
 
 	savedTask, err := s.taskRepo.Create(ctx, task)
 	duration := time.Since(start)
@@ -68,7 +220,20 @@ func (s *taskService) GetTask(ctx context.Context, req *pb.GetTaskRequest) (*pb.
 		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
 	}
 
+	ctx, serviceErr := s.authorize(ctx, id, model.PermissionRead)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, id.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
 	task, err := s.taskRepo.GetByID(ctx, id)
+
+	var commentCount *int64
+	if err == nil && req.GetIncludeCommentCount() {
+		var count int64
+		count, err = s.commentRepo.Count(ctx, id)
+		commentCount = &count
+	}
 	duration := time.Since(start)
 
 	if err != nil {
@@ -80,7 +245,8 @@ func (s *taskService) GetTask(ctx context.Context, req *pb.GetTaskRequest) (*pb.
 	logger.LogTaskOperation(ctx, operation, task.ID.String(), duration, nil)
 
 	return &pb.TaskResponse{
-		Task: model.TaskToProto(task),
+		Task:         model.TaskToProto(task),
+		CommentCount: commentCount,
 	}, nil
 }
 
@@ -94,6 +260,12 @@ func (s *taskService) UpdateTask(ctx context.Context, req *pb.UpdateTaskRequest)
 		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
 	}
 
+	ctx, serviceErr := s.authorize(ctx, id, updatePermission(req))
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, id.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
 	task, err := s.taskRepo.GetByID(ctx, id)
 	if err != nil {
 		duration := time.Since(start)
@@ -102,14 +274,79 @@ func (s *taskService) UpdateTask(ctx context.Context, req *pb.UpdateTaskRequest)
 		return nil, serviceErr.ToGRPCStatus()
 	}
 
-	title, description, completed := model.UpdateTaskRequestFromProto(req)
-	task.Update(title, description, completed)
+	update := model.UpdateTaskRequestFromProto(req)
+	derived := task.CompletedFromItems
+	if update.CompletedFromItems != nil {
+		derived = *update.CompletedFromItems
+	}
+	if derived && update.Completed != nil {
+		logger.LogError(ctx, errors.ErrCompletedDerived, operation)
+		return nil, errors.ErrCompletedDerived.ToGRPCStatus()
+	}
+	if update.Recurrence != nil && model.ValidateRecurrence(*update.Recurrence) != nil {
+		logger.LogError(ctx, errors.ErrInvalidRecurrence, operation)
+		return nil, errors.ErrInvalidRecurrence.ToGRPCStatus()
+	}
+
+	if err := update.NormalizeTags(); err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTags, operation)
+		return nil, errors.ErrInvalidTags.ToGRPCStatus()
+	}
+	if update.Priority != nil && !update.Priority.Valid() {
+		logger.LogError(ctx, errors.ErrInvalidPriority, operation)
+		return nil, errors.ErrInvalidPriority.ToGRPCStatus()
+	}
+	if update.ListID, err = model.ListIDFromProto(req.ListId); err != nil {
+		logger.LogError(ctx, errors.ErrInvalidListId, operation)
+		return nil, errors.ErrInvalidListId.ToGRPCStatus()
+	}
+	if update.ParentID, err = model.ParentIDFromProto(req.ParentId); err != nil {
+		logger.LogError(ctx, errors.ErrInvalidParentId, operation)
+		return nil, errors.ErrInvalidParentId.ToGRPCStatus()
+	}
+	if update.SetCustomFields, err = model.CustomFieldValuesFromProto(req.SetCustomFields); err != nil {
+		logger.LogError(ctx, errors.ErrInvalidCustomFieldValue, operation)
+		return nil, errors.ErrInvalidCustomFieldValue.ToGRPCStatus()
+	}
 
-	updatedTask, err := s.taskRepo.Update(ctx, task)
+	wasCompleted := task.Completed
+	task.Update(update)
+	if len(task.Tags) > model.MaxTagsPerTask {
+		logger.LogError(ctx, errors.ErrInvalidTags, operation)
+		return nil, errors.ErrInvalidTags.ToGRPCStatus()
+	}
+	schemas := make(map[uuid.UUID]model.CustomFieldSchema)
+	if serviceErr := s.validateCustomFields(ctx, task.ListID, update.SetCustomFields, schemas); serviceErr != nil {
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+	completing := !wasCompleted && task.Completed
+	cascade := completing && req.Cascade
+
+	// Completing the subtasks checks their blockers as it goes.
+	var updatedTask, nextTask *model.Task
+	err = s.taskRepo.WithTx(ctx, func(ctx context.Context) error {
+		if completing {
+			if err := s.checkBlockers(ctx, task.ID); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		switch {
+		case cascade:
+			updatedTask, nextTask, err = s.completeCascading(ctx, task, req.ExpectedVersion)
+		case completing && task.Recurrence != "":
+			updatedTask, nextTask, err = s.completeRecurring(ctx, task, req.ExpectedVersion)
+		default:
+			updatedTask, err = s.taskRepo.Update(ctx, task, req.ExpectedVersion)
+		}
+		return err
+	})
 	duration := time.Since(start)
 
 	if err != nil {
-		serviceErr := errors.WrapRepositoryError(err)
+		serviceErr := taskRepositoryError(err)
 		logger.LogTaskOperation(ctx, operation, task.ID.String(), duration, serviceErr)
 		return nil, serviceErr.ToGRPCStatus()
 	}
@@ -117,7 +354,8 @@ func (s *taskService) UpdateTask(ctx context.Context, req *pb.UpdateTaskRequest)
 	logger.LogTaskOperation(ctx, operation, updatedTask.ID.String(), duration, nil)
 
 	return &pb.TaskResponse{
-		Task: model.TaskToProto(updatedTask),
+		Task:           model.TaskToProto(updatedTask),
+		NextOccurrence: model.TaskToProto(nextTask),
 	}, nil
 }
 
@@ -131,11 +369,22 @@ func (s *taskService) DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest)
 		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
 	}
 
-	err = s.taskRepo.DeleteByID(ctx, id)
+	// Deleting the subtasks along with the task is left to the owner.
+	required := model.PermissionEdit
+	if req.Cascade {
+		required = model.PermissionOwner
+	}
+	ctx, serviceErr := s.authorize(ctx, id, required)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, id.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	err = s.taskRepo.DeleteByID(ctx, id, req.ExpectedVersion, req.Cascade)
 	duration := time.Since(start)
 
 	if err != nil {
-		serviceErr := errors.WrapRepositoryError(err)
+		serviceErr := taskRepositoryError(err)
 		logger.LogTaskOperation(ctx, operation, id.String(), duration, serviceErr)
 		return nil, serviceErr.ToGRPCStatus()
 	}
@@ -147,11 +396,131 @@ func (s *taskService) DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest)
 	}, nil
 }
 
+func (s *taskService) RestoreTask(ctx context.Context, req *pb.RestoreTaskRequest) (*pb.TaskResponse, error) {
+	start := time.Now()
+	operation := "RestoreTask"
+
+	id, err := model.RestoreTaskRequestFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTaskId, operation)
+		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
+	}
+
+	ctx, serviceErr := s.authorize(ctx, id, model.PermissionEdit)
+	if serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, id.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	task, err := s.taskRepo.Restore(ctx, id)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := taskRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, id.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, task.ID.String(), duration, nil)
+
+	return &pb.TaskResponse{
+		Task: model.TaskToProto(task),
+	}, nil
+}
+
+func (s *taskService) MoveTask(ctx context.Context, req *pb.MoveTaskRequest) (*pb.TaskResponse, error) {
+	start := time.Now()
+	operation := "MoveTask"
+
+	id, anchorID, before, err := model.MoveTaskRequestFromProto(req)
+	if err != nil {
+		serviceErr := errors.ErrInvalidTaskId
+		if stderrors.Is(err, model.ErrInvalidMove) {
+			serviceErr = errors.ErrInvalidMove
+		}
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	// The manual order is the owner's own.
+	if _, serviceErr := s.authorize(ctx, id, model.PermissionOwner); serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, id.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	task, err := s.taskRepo.Move(ctx, id, anchorID, before)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := taskRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, id.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, task.ID.String(), duration, nil)
+
+	return &pb.TaskResponse{
+		Task: model.TaskToProto(task),
+	}, nil
+}
+
+func (s *taskService) PurgeTask(ctx context.Context, req *pb.PurgeTaskRequest) (*pb.PurgeTaskResponse, error) {
+	start := time.Now()
+	operation := "PurgeTask"
+
+	id, err := model.PurgeTaskRequestFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTaskId, operation)
+		return nil, errors.ErrInvalidTaskId.ToGRPCStatus()
+	}
+
+	if _, serviceErr := s.authorize(ctx, id, model.PermissionOwner); serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, id.String(), time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	err = s.taskRepo.Purge(ctx, id)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := taskRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, id.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, id.String(), duration, nil)
+
+	return &pb.PurgeTaskResponse{
+		Success: true,
+	}, nil
+}
+
 func (s *taskService) ListTasks(ctx context.Context, req *pb.ListTasksRequest) (*pb.ListTasksResponse, error) {
 	start := time.Now()
 	operation := "ListTasks"
 
-	tasks, err := s.taskRepo.List(ctx)
+	if req.PageSize < 0 {
+		logger.LogError(ctx, errors.ErrInvalidPageSize, operation)
+		return nil, errors.ErrInvalidPageSize.ToGRPCStatus()
+	}
+
+	opts, err := model.ListTasksRequestFromProto(req)
+	if err == nil {
+		err = opts.Filter.Validate()
+	}
+	if err != nil {
+		serviceErr := listOptionsError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+	opts.PageSize = opts.Limit()
+
+	if serviceErr := s.validateCustomFieldFilters(ctx, opts.Filter); serviceErr != nil {
+		logger.LogTaskOperation(ctx, operation, "", time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	page, err := s.taskRepo.List(ctx, opts)
 	duration := time.Since(start)
 
 	if err != nil {
@@ -163,6 +532,65 @@ func (s *taskService) ListTasks(ctx context.Context, req *pb.ListTasksRequest) (
 	logger.LogTaskOperation(ctx, operation, "", duration, nil)
 
 	return &pb.ListTasksResponse{
-		Tasks: model.TasksToProto(tasks),
+		Tasks:         model.TasksToProto(page.Tasks),
+		NextPageToken: page.NextCursor.Encode(),
+	}, nil
+}
+
+func (s *taskService) SearchTasks(ctx context.Context, req *pb.SearchTasksRequest) (*pb.SearchTasksResponse, error) {
+	start := time.Now()
+	operation := "SearchTasks"
+
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		logger.LogError(ctx, errors.ErrQueryNotSpecified, operation)
+		return nil, errors.ErrQueryNotSpecified.ToGRPCStatus()
+	}
+	if req.PageSize < 0 {
+		logger.LogError(ctx, errors.ErrInvalidPageSize, operation)
+		return nil, errors.ErrInvalidPageSize.ToGRPCStatus()
+	}
+
+	limit := model.PageLimit(int(req.PageSize))
+
+	results, err := s.taskRepo.Search(ctx, query, limit)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.SearchTasksResponse{
+		Results: model.SearchResultsToProto(results),
 	}, nil
-}
\ No newline at end of file
+}
+
+func taskRepositoryError(err error) *errors.ServiceError {
+	switch {
+	case repository.IsVersionMismatchError(err):
+		return errors.ErrVersionMismatch
+	default:
+		return errors.WrapRepositoryError(err)
+	}
+}
+
+func listOptionsError(err error) *errors.ServiceError {
+	switch {
+	case stderrors.Is(err, model.ErrInvalidSort):
+		return errors.ErrInvalidSort
+	case stderrors.Is(err, model.ErrInvalidFilter):
+		return errors.ErrInvalidFilter
+	case stderrors.Is(err, model.ErrInvalidTag):
+		return errors.ErrInvalidTags
+	case stderrors.Is(err, model.ErrInvalidListID):
+		return errors.ErrInvalidListId
+	case stderrors.Is(err, model.ErrInvalidCustomFieldFilter):
+		return errors.ErrInvalidCustomFieldFilter
+	default:
+		return errors.ErrInvalidPageToken
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+)
+
+func (s *taskService) CreateTaskList(ctx context.Context, req *pb.CreateTaskListRequest) (*pb.TaskListResponse, error) {
+	start := time.Now()
+	operation := "CreateTaskList"
+
+	name, description := model.CreateTaskListRequestFromProto(req)
+	if name == "" {
+		logger.LogError(ctx, errors.ErrListNameRequired, operation)
+		return nil, errors.ErrListNameRequired.ToGRPCStatus()
+	}
+
+	list, err := s.listRepo.Create(ctx, model.NewTaskList(name, description))
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, list.ID.String(), duration, nil)
+
+	return &pb.TaskListResponse{
+		List: model.TaskListToProto(list),
+	}, nil
+}
+
+func (s *taskService) GetTaskList(ctx context.Context, req *pb.GetTaskListRequest) (*pb.TaskListResponse, error) {
+	start := time.Now()
+	operation := "GetTaskList"
+
+	id, err := model.GetTaskListRequestFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidListId, operation)
+		return nil, errors.ErrInvalidListId.ToGRPCStatus()
+	}
+
+	list, err := s.listRepo.GetByID(ctx, id)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, id.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, id.String(), duration, nil)
+
+	return &pb.TaskListResponse{
+		List: model.TaskListToProto(list),
+	}, nil
+}
+
+func (s *taskService) UpdateTaskList(ctx context.Context, req *pb.UpdateTaskListRequest) (*pb.TaskListResponse, error) {
+	start := time.Now()
+	operation := "UpdateTaskList"
+
+	id, update, err := model.UpdateTaskListRequestFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidListId, operation)
+		return nil, errors.ErrInvalidListId.ToGRPCStatus()
+	}
+	if update.Name != nil && *update.Name == "" {
+		logger.LogError(ctx, errors.ErrListNameRequired, operation)
+		return nil, errors.ErrListNameRequired.ToGRPCStatus()
+	}
+
+	list, err := s.listRepo.GetByID(ctx, id)
+	if err == nil {
+		list.Update(update)
+		list, err = s.listRepo.Update(ctx, list)
+	}
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, id.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, id.String(), duration, nil)
+
+	return &pb.TaskListResponse{
+		List: model.TaskListToProto(list),
+	}, nil
+}
+
+func (s *taskService) DeleteTaskList(ctx context.Context, req *pb.DeleteTaskListRequest) (*pb.DeleteTaskListResponse, error) {
+	start := time.Now()
+	operation := "DeleteTaskList"
+
+	id, err := model.DeleteTaskListRequestFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidListId, operation)
+		return nil, errors.ErrInvalidListId.ToGRPCStatus()
+	}
+
+	err = s.listRepo.Delete(ctx, id)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, id.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, id.String(), duration, nil)
+
+	return &pb.DeleteTaskListResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *taskService) ListTaskLists(ctx context.Context, req *pb.ListTaskListsRequest) (*pb.ListTaskListsResponse, error) {
+	start := time.Now()
+	operation := "ListTaskLists"
+
+	if req.PageSize < 0 {
+		logger.LogError(ctx, errors.ErrInvalidPageSize, operation)
+		return nil, errors.ErrInvalidPageSize.ToGRPCStatus()
+	}
+
+	limit, cursor, err := model.ListTaskListsRequestFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidPageToken, operation)
+		return nil, errors.ErrInvalidPageToken.ToGRPCStatus()
+	}
+
+	lists, next, err := s.listRepo.List(ctx, limit, cursor)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.ListTaskListsResponse{
+		Lists:         model.TaskListsToProto(lists),
+		NextPageToken: next.Encode(),
+	}, nil
+}
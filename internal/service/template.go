@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+	"github.com/google/uuid"
+)
+
+func (s *taskService) CreateTemplate(ctx context.Context, req *pb.CreateTemplateRequest) (*pb.TemplateResponse, error) {
+	start := time.Now()
+	operation := "CreateTemplate"
+
+	if req.Title == "" {
+		logger.LogError(ctx, errors.ErrTitleNotSpecified, operation)
+		return nil, errors.ErrTitleNotSpecified.ToGRPCStatus()
+	}
+
+	template, err := model.CreateTemplateRequestFromProto(req)
+	if err != nil {
+		serviceErr := templateRequestError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+	if serviceErr := s.validateTemplate(ctx, template); serviceErr != nil {
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	created, err := s.templateRepo.Create(ctx, template)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.TemplateResponse{
+		Template: model.TaskTemplateToProto(created),
+	}, nil
+}
+
+func (s *taskService) GetTemplate(ctx context.Context, req *pb.GetTemplateRequest) (*pb.TemplateResponse, error) {
+	start := time.Now()
+	operation := "GetTemplate"
+
+	id, err := model.GetTemplateRequestFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTemplateId, operation)
+		return nil, errors.ErrInvalidTemplateId.ToGRPCStatus()
+	}
+
+	template, err := s.templateRepo.GetByID(ctx, id)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.TemplateResponse{
+		Template: model.TaskTemplateToProto(template),
+	}, nil
+}
+
+func (s *taskService) UpdateTemplate(ctx context.Context, req *pb.UpdateTemplateRequest) (*pb.TemplateResponse, error) {
+	start := time.Now()
+	operation := "UpdateTemplate"
+
+	template, err := model.UpdateTemplateRequestFromProto(req)
+	if err != nil {
+		serviceErr := templateRequestError(err)
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+	if template.Title == "" {
+		logger.LogError(ctx, errors.ErrTitleNotSpecified, operation)
+		return nil, errors.ErrTitleNotSpecified.ToGRPCStatus()
+	}
+	if serviceErr := s.validateTemplate(ctx, template); serviceErr != nil {
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	updated, err := s.templateRepo.Update(ctx, template)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.TemplateResponse{
+		Template: model.TaskTemplateToProto(updated),
+	}, nil
+}
+
+func (s *taskService) DeleteTemplate(ctx context.Context, req *pb.DeleteTemplateRequest) (*pb.DeleteTemplateResponse, error) {
+	start := time.Now()
+	operation := "DeleteTemplate"
+
+	id, err := model.DeleteTemplateRequestFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTemplateId, operation)
+		return nil, errors.ErrInvalidTemplateId.ToGRPCStatus()
+	}
+
+	err = s.templateRepo.Delete(ctx, id)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.DeleteTemplateResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *taskService) ListTemplates(ctx context.Context, req *pb.ListTemplatesRequest) (*pb.ListTemplatesResponse, error) {
+	start := time.Now()
+	operation := "ListTemplates"
+
+	if req.PageSize < 0 {
+		logger.LogError(ctx, errors.ErrInvalidPageSize, operation)
+		return nil, errors.ErrInvalidPageSize.ToGRPCStatus()
+	}
+
+	limit, cursor, err := model.ListTemplatesRequestFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidPageToken, operation)
+		return nil, errors.ErrInvalidPageToken.ToGRPCStatus()
+	}
+
+	templates, next, err := s.templateRepo.List(ctx, limit, cursor)
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, "", duration, nil)
+
+	return &pb.ListTemplatesResponse{
+		Templates:     model.TaskTemplatesToProto(templates),
+		NextPageToken: next.Encode(),
+	}, nil
+}
+
+// InstantiateTemplate creates the task a template describes and its
+// subtasks in one transaction. The custom fields of the template are
+// checked again, as the fields of its list may have changed since it was
+// saved.
+func (s *taskService) InstantiateTemplate(ctx context.Context, req *pb.InstantiateTemplateRequest) (*pb.InstantiateTemplateResponse, error) {
+	start := time.Now()
+	operation := "InstantiateTemplate"
+
+	id, params, err := model.InstantiateTemplateRequestFromProto(req)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTemplateId, operation)
+		return nil, errors.ErrInvalidTemplateId.ToGRPCStatus()
+	}
+
+	template, err := s.templateRepo.GetByID(ctx, id)
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, "", time.Since(start), serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	task, subtasks, err := template.Instantiate(params, time.Now())
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidTemplateParams, operation)
+		return nil, errors.ErrInvalidTemplateParams.ToGRPCStatus()
+	}
+	schemas := make(map[uuid.UUID]model.CustomFieldSchema)
+	if serviceErr := s.validateCustomFields(ctx, task.ListID, task.CustomFields, schemas); serviceErr != nil {
+		logger.LogError(ctx, serviceErr, operation)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	var createdTask *model.Task
+	createdSubtasks := make([]*model.Task, 0, len(subtasks))
+	err = s.taskRepo.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		createdTask, err = s.taskRepo.Create(ctx, task)
+		if err != nil {
+			return err
+		}
+		for _, subtask := range subtasks {
+			createdSubtask, err := s.taskRepo.Create(ctx, subtask)
+			if err != nil {
+				return err
+			}
+			createdSubtasks = append(createdSubtasks, createdSubtask)
+		}
+		return nil
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		serviceErr := errors.WrapRepositoryError(err)
+		logger.LogTaskOperation(ctx, operation, task.ID.String(), duration, serviceErr)
+		return nil, serviceErr.ToGRPCStatus()
+	}
+
+	logger.LogTaskOperation(ctx, operation, createdTask.ID.String(), duration, nil)
+
+	return &pb.InstantiateTemplateResponse{
+		Task:     model.TaskToProto(createdTask),
+		Subtasks: model.TasksToProto(createdSubtasks),
+	}, nil
+}
+
+// validateTemplate checks a template the way a task with the same fields is
+// checked on creation and normalizes its tags.
+func (s *taskService) validateTemplate(ctx context.Context, template *model.TaskTemplate) *errors.ServiceError {
+	if err := template.Validate(); err != nil {
+		return errors.ErrInvalidTemplate
+	}
+	if !template.Priority.Valid() {
+		return errors.ErrInvalidPriority
+	}
+
+	tags, serviceErr := tagsFromRequest(template.Tags)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	if tags != nil {
+		template.Tags = tags
+	}
+
+	schemas := make(map[uuid.UUID]model.CustomFieldSchema)
+	return s.validateCustomFields(ctx, template.ListID, template.CustomFields, schemas)
+}
+
+func templateRequestError(err error) *errors.ServiceError {
+	switch {
+	case stderrors.Is(err, model.ErrInvalidTemplateID):
+		return errors.ErrInvalidTemplateId
+	case stderrors.Is(err, model.ErrInvalidListID):
+		return errors.ErrInvalidListId
+	default:
+		return errors.ErrInvalidCustomFieldValue
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/errors"
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+	pb "github.com/Raisondetr3/checklist-db-service/pkg/pb"
+)
+
+const (
+	watchBatchSize = 100
+	// Events held back behind a long-running transaction are not announced
+	// again once it finishes, so watchers also poll at this interval.
+	watchPollInterval = 5 * time.Second
+)
+
+// WatchTasks streams the caller's task events. The stream never buffers
+// more than one batch: Send blocks on gRPC flow control while the client is
+// slow, and the watcher then reads everything it fell behind on from the
+// event log, keyed by the resume token of the last event it sent.
+func (s *taskService) WatchTasks(req *pb.WatchTasksRequest, stream pb.TaskService_WatchTasksServer) error {
+	ctx := stream.Context()
+	start := time.Now()
+	operation := "WatchTasks"
+
+	caller, err := identity.FromContext(ctx)
+	if err != nil {
+		logger.LogError(ctx, err, operation)
+		return errors.ErrInternalError.ToGRPCStatus()
+	}
+
+	resume, err := model.DecodeEventPosition(req.ResumeToken)
+	if err != nil {
+		logger.LogError(ctx, errors.ErrInvalidResumeToken, operation)
+		return errors.ErrInvalidResumeToken.ToGRPCStatus()
+	}
+
+	// Subscribe before reading the log so that no notification is missed
+	// between the first read and waiting for the next one.
+	sub := s.watchHub.Subscribe(caller)
+	defer sub.Close()
+
+	var position model.EventPosition
+	if resume != nil {
+		expired, err := s.eventRepo.Expired(ctx, *resume)
+		if err != nil {
+			serviceErr := errors.WrapRepositoryError(err)
+			logger.LogTaskOperation(ctx, operation, "", time.Since(start), serviceErr)
+			return serviceErr.ToGRPCStatus()
+		}
+		if expired {
+			logger.LogError(ctx, errors.ErrResumeTokenExpired, operation)
+			return errors.ErrResumeTokenExpired.ToGRPCStatus()
+		}
+		position = *resume
+	} else {
+		position, err = s.eventRepo.Head(ctx)
+		if err != nil {
+			serviceErr := errors.WrapRepositoryError(err)
+			logger.LogTaskOperation(ctx, operation, "", time.Since(start), serviceErr)
+			return serviceErr.ToGRPCStatus()
+		}
+	}
+
+	poll := time.NewTicker(watchPollInterval)
+	defer poll.Stop()
+
+	for {
+		events, err := s.eventRepo.ListAfter(ctx, position, watchBatchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				logger.LogTaskOperation(ctx, operation, "", time.Since(start), nil)
+				return nil
+			}
+			serviceErr := errors.WrapRepositoryError(err)
+			logger.LogTaskOperation(ctx, operation, "", time.Since(start), serviceErr)
+			return serviceErr.ToGRPCStatus()
+		}
+
+		for _, event := range events {
+			if err := stream.Send(model.TaskEventToProto(event)); err != nil {
+				logger.LogTaskOperation(ctx, operation, "", time.Since(start), nil)
+				return err
+			}
+			position = event.Position
+		}
+
+		if len(events) == watchBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.LogTaskOperation(ctx, operation, "", time.Since(start), nil)
+			return nil
+		case <-sub.Wake():
+		case <-poll.C:
+		}
+	}
+}
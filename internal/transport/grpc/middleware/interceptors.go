@@ -2,10 +2,12 @@ package middleware
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"log/slog"
 
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
 	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
 	"github.com/google/uuid"
 	"google.golang.org/grpc"
@@ -33,6 +35,47 @@ func RequestIDUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.
 	return handler(ctx, req)
 }
 
+const (
+	TenantIDMetadataKey = "x-tenant-id"
+	UserIDMetadataKey   = "x-user-id"
+)
+
+// IdentityUnaryInterceptor takes the caller's tenant and user from request
+// metadata. Requests without a user id are rejected; a missing tenant falls
+// back to identity.DefaultTenantID for single-tenant deployments.
+func IdentityUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	id, err := identityFromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(identity.WithIdentity(ctx, id), req)
+}
+
+func identityFromMetadata(ctx context.Context) (identity.Identity, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := identity.Identity{
+		TenantID: firstMetadataValue(md, TenantIDMetadataKey),
+		UserID:   firstMetadataValue(md, UserIDMetadataKey),
+	}
+	if id.UserID == "" {
+		return identity.Identity{}, status.Error(codes.Unauthenticated, "missing "+UserIDMetadataKey+" metadata")
+	}
+	if id.TenantID == "" {
+		id.TenantID = identity.DefaultTenantID
+	}
+
+	return id, nil
+}
+
+func firstMetadataValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
 func PanicRecoveryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -58,3 +101,68 @@ func ChainUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) grpc.Un
 		return chain(ctx, req)
 	}
 }
+
+// contextStream lets stream interceptors hand a derived context to the
+// handler.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func LoggingStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	duration := time.Since(start)
+
+	logger.LogGRPCRequest(ss.Context(), info.FullMethod, duration, err)
+	return err
+}
+
+func RequestIDStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	requestID := uuid.New().String()
+	ctx := context.WithValue(ss.Context(), "request_id", requestID)
+
+	header := metadata.New(map[string]string{"x-request-id": requestID})
+	ss.SetHeader(header)
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+func IdentityStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	id, err := identityFromMetadata(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: identity.WithIdentity(ss.Context(), id)})
+}
+
+func PanicRecoveryStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Panic recovered in gRPC stream handler",
+				slog.String("method", info.FullMethod),
+				slog.Any("panic", r))
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+	return handler(srv, ss)
+}
+
+func ChainStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		chain := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor := interceptors[i]
+			currentHandler := chain
+			chain = func(currentSrv interface{}, currentStream grpc.ServerStream) error {
+				return interceptor(currentSrv, currentStream, info, currentHandler)
+			}
+		}
+		return chain(srv, ss)
+	}
+}
@@ -26,6 +26,15 @@ func NewGRPCServer(cfg *config.Config, taskService service.TaskService) *GRPCSer
 				middleware.PanicRecoveryUnaryInterceptor,
 				middleware.RequestIDUnaryInterceptor,
 				middleware.LoggingUnaryInterceptor,
+				middleware.IdentityUnaryInterceptor,
+			),
+		),
+		grpc.StreamInterceptor(
+			middleware.ChainStreamInterceptors(
+				middleware.PanicRecoveryStreamInterceptor,
+				middleware.RequestIDStreamInterceptor,
+				middleware.LoggingStreamInterceptor,
+				middleware.IdentityStreamInterceptor,
 			),
 		),
 	)
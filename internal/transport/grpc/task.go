@@ -25,3 +25,187 @@ func (s *GRPCServer) DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest)
 func (s *GRPCServer) ListTasks(ctx context.Context, req *pb.ListTasksRequest) (*pb.ListTasksResponse, error) {
 	return s.taskService.ListTasks(ctx, req)
 }
+
+func (s *GRPCServer) RestoreTask(ctx context.Context, req *pb.RestoreTaskRequest) (*pb.TaskResponse, error) {
+	return s.taskService.RestoreTask(ctx, req)
+}
+
+func (s *GRPCServer) PurgeTask(ctx context.Context, req *pb.PurgeTaskRequest) (*pb.PurgeTaskResponse, error) {
+	return s.taskService.PurgeTask(ctx, req)
+}
+
+func (s *GRPCServer) SearchTasks(ctx context.Context, req *pb.SearchTasksRequest) (*pb.SearchTasksResponse, error) {
+	return s.taskService.SearchTasks(ctx, req)
+}
+
+func (s *GRPCServer) GetTaskHistory(ctx context.Context, req *pb.GetTaskHistoryRequest) (*pb.GetTaskHistoryResponse, error) {
+	return s.taskService.GetTaskHistory(ctx, req)
+}
+
+func (s *GRPCServer) ListOccurrences(ctx context.Context, req *pb.ListOccurrencesRequest) (*pb.ListOccurrencesResponse, error) {
+	return s.taskService.ListOccurrences(ctx, req)
+}
+
+func (s *GRPCServer) ListTags(ctx context.Context, req *pb.ListTagsRequest) (*pb.ListTagsResponse, error) {
+	return s.taskService.ListTags(ctx, req)
+}
+
+func (s *GRPCServer) MoveTask(ctx context.Context, req *pb.MoveTaskRequest) (*pb.TaskResponse, error) {
+	return s.taskService.MoveTask(ctx, req)
+}
+
+func (s *GRPCServer) AddDependency(ctx context.Context, req *pb.DependencyRequest) (*pb.DependencyResponse, error) {
+	return s.taskService.AddDependency(ctx, req)
+}
+
+func (s *GRPCServer) RemoveDependency(ctx context.Context, req *pb.DependencyRequest) (*pb.DependencyResponse, error) {
+	return s.taskService.RemoveDependency(ctx, req)
+}
+
+func (s *GRPCServer) GetTaskGraph(ctx context.Context, req *pb.GetTaskGraphRequest) (*pb.GetTaskGraphResponse, error) {
+	return s.taskService.GetTaskGraph(ctx, req)
+}
+
+func (s *GRPCServer) GetTaskTree(ctx context.Context, req *pb.GetTaskTreeRequest) (*pb.GetTaskTreeResponse, error) {
+	return s.taskService.GetTaskTree(ctx, req)
+}
+
+func (s *GRPCServer) ShareTask(ctx context.Context, req *pb.ShareTaskRequest) (*pb.TaskAccessResponse, error) {
+	return s.taskService.ShareTask(ctx, req)
+}
+
+func (s *GRPCServer) UnshareTask(ctx context.Context, req *pb.UnshareTaskRequest) (*pb.TaskAccessResponse, error) {
+	return s.taskService.UnshareTask(ctx, req)
+}
+
+func (s *GRPCServer) AssignTask(ctx context.Context, req *pb.AssignTaskRequest) (*pb.TaskAccessResponse, error) {
+	return s.taskService.AssignTask(ctx, req)
+}
+
+func (s *GRPCServer) UnassignTask(ctx context.Context, req *pb.AssignTaskRequest) (*pb.TaskAccessResponse, error) {
+	return s.taskService.UnassignTask(ctx, req)
+}
+
+func (s *GRPCServer) GetTaskAccess(ctx context.Context, req *pb.GetTaskAccessRequest) (*pb.TaskAccessResponse, error) {
+	return s.taskService.GetTaskAccess(ctx, req)
+}
+
+func (s *GRPCServer) AddComment(ctx context.Context, req *pb.AddCommentRequest) (*pb.CommentResponse, error) {
+	return s.taskService.AddComment(ctx, req)
+}
+
+func (s *GRPCServer) EditComment(ctx context.Context, req *pb.EditCommentRequest) (*pb.CommentResponse, error) {
+	return s.taskService.EditComment(ctx, req)
+}
+
+func (s *GRPCServer) DeleteComment(ctx context.Context, req *pb.DeleteCommentRequest) (*pb.DeleteCommentResponse, error) {
+	return s.taskService.DeleteComment(ctx, req)
+}
+
+func (s *GRPCServer) ListComments(ctx context.Context, req *pb.ListCommentsRequest) (*pb.ListCommentsResponse, error) {
+	return s.taskService.ListComments(ctx, req)
+}
+
+func (s *GRPCServer) UploadAttachment(stream pb.TaskService_UploadAttachmentServer) error {
+	return s.taskService.UploadAttachment(stream)
+}
+
+func (s *GRPCServer) DownloadAttachment(req *pb.DownloadAttachmentRequest, stream pb.TaskService_DownloadAttachmentServer) error {
+	return s.taskService.DownloadAttachment(req, stream)
+}
+
+func (s *GRPCServer) ListAttachments(ctx context.Context, req *pb.ListAttachmentsRequest) (*pb.ListAttachmentsResponse, error) {
+	return s.taskService.ListAttachments(ctx, req)
+}
+
+func (s *GRPCServer) DeleteAttachment(ctx context.Context, req *pb.DeleteAttachmentRequest) (*pb.DeleteAttachmentResponse, error) {
+	return s.taskService.DeleteAttachment(ctx, req)
+}
+
+func (s *GRPCServer) CreateTaskList(ctx context.Context, req *pb.CreateTaskListRequest) (*pb.TaskListResponse, error) {
+	return s.taskService.CreateTaskList(ctx, req)
+}
+
+func (s *GRPCServer) GetTaskList(ctx context.Context, req *pb.GetTaskListRequest) (*pb.TaskListResponse, error) {
+	return s.taskService.GetTaskList(ctx, req)
+}
+
+func (s *GRPCServer) UpdateTaskList(ctx context.Context, req *pb.UpdateTaskListRequest) (*pb.TaskListResponse, error) {
+	return s.taskService.UpdateTaskList(ctx, req)
+}
+
+func (s *GRPCServer) DeleteTaskList(ctx context.Context, req *pb.DeleteTaskListRequest) (*pb.DeleteTaskListResponse, error) {
+	return s.taskService.DeleteTaskList(ctx, req)
+}
+
+func (s *GRPCServer) ListTaskLists(ctx context.Context, req *pb.ListTaskListsRequest) (*pb.ListTaskListsResponse, error) {
+	return s.taskService.ListTaskLists(ctx, req)
+}
+
+func (s *GRPCServer) CreateCustomField(ctx context.Context, req *pb.CreateCustomFieldRequest) (*pb.CustomFieldResponse, error) {
+	return s.taskService.CreateCustomField(ctx, req)
+}
+
+func (s *GRPCServer) ListCustomFields(ctx context.Context, req *pb.ListCustomFieldsRequest) (*pb.ListCustomFieldsResponse, error) {
+	return s.taskService.ListCustomFields(ctx, req)
+}
+
+func (s *GRPCServer) DeleteCustomField(ctx context.Context, req *pb.DeleteCustomFieldRequest) (*pb.DeleteCustomFieldResponse, error) {
+	return s.taskService.DeleteCustomField(ctx, req)
+}
+
+func (s *GRPCServer) CreateTemplate(ctx context.Context, req *pb.CreateTemplateRequest) (*pb.TemplateResponse, error) {
+	return s.taskService.CreateTemplate(ctx, req)
+}
+
+func (s *GRPCServer) GetTemplate(ctx context.Context, req *pb.GetTemplateRequest) (*pb.TemplateResponse, error) {
+	return s.taskService.GetTemplate(ctx, req)
+}
+
+func (s *GRPCServer) UpdateTemplate(ctx context.Context, req *pb.UpdateTemplateRequest) (*pb.TemplateResponse, error) {
+	return s.taskService.UpdateTemplate(ctx, req)
+}
+
+func (s *GRPCServer) DeleteTemplate(ctx context.Context, req *pb.DeleteTemplateRequest) (*pb.DeleteTemplateResponse, error) {
+	return s.taskService.DeleteTemplate(ctx, req)
+}
+
+func (s *GRPCServer) ListTemplates(ctx context.Context, req *pb.ListTemplatesRequest) (*pb.ListTemplatesResponse, error) {
+	return s.taskService.ListTemplates(ctx, req)
+}
+
+func (s *GRPCServer) InstantiateTemplate(ctx context.Context, req *pb.InstantiateTemplateRequest) (*pb.InstantiateTemplateResponse, error) {
+	return s.taskService.InstantiateTemplate(ctx, req)
+}
+
+func (s *GRPCServer) BatchCreateTasks(ctx context.Context, req *pb.BatchCreateTasksRequest) (*pb.BatchTasksResponse, error) {
+	return s.taskService.BatchCreateTasks(ctx, req)
+}
+
+func (s *GRPCServer) BatchUpdateTasks(ctx context.Context, req *pb.BatchUpdateTasksRequest) (*pb.BatchTasksResponse, error) {
+	return s.taskService.BatchUpdateTasks(ctx, req)
+}
+
+func (s *GRPCServer) BatchDeleteTasks(ctx context.Context, req *pb.BatchDeleteTasksRequest) (*pb.BatchTasksResponse, error) {
+	return s.taskService.BatchDeleteTasks(ctx, req)
+}
+
+func (s *GRPCServer) AddChecklistItem(ctx context.Context, req *pb.AddChecklistItemRequest) (*pb.TaskResponse, error) {
+	return s.taskService.AddChecklistItem(ctx, req)
+}
+
+func (s *GRPCServer) ReorderChecklistItems(ctx context.Context, req *pb.ReorderChecklistItemsRequest) (*pb.TaskResponse, error) {
+	return s.taskService.ReorderChecklistItems(ctx, req)
+}
+
+func (s *GRPCServer) ToggleChecklistItem(ctx context.Context, req *pb.ToggleChecklistItemRequest) (*pb.TaskResponse, error) {
+	return s.taskService.ToggleChecklistItem(ctx, req)
+}
+
+func (s *GRPCServer) DeleteChecklistItem(ctx context.Context, req *pb.DeleteChecklistItemRequest) (*pb.TaskResponse, error) {
+	return s.taskService.DeleteChecklistItem(ctx, req)
+}
+
+func (s *GRPCServer) WatchTasks(req *pb.WatchTasksRequest, stream pb.TaskService_WatchTasksServer) error {
+	return s.taskService.WatchTasks(req, stream)
+}
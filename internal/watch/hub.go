@@ -0,0 +1,90 @@
+package watch
+
+import (
+	"sync"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+)
+
+// Hub fans task change notifications out to the watchers of the affected
+// tenant and owner. A notification only carries "something changed": every
+// watcher has a single-slot wake channel, so notifications for a watcher
+// that is busy sending coalesce instead of queueing, and the watcher reads
+// what it missed from the event log once it catches up.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[identity.Identity]map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[identity.Identity]map[*Subscription]struct{}),
+	}
+}
+
+type Subscription struct {
+	hub    *Hub
+	caller identity.Identity
+	wake   chan struct{}
+}
+
+func (h *Hub) Subscribe(caller identity.Identity) *Subscription {
+	sub := &Subscription{
+		hub:    h,
+		caller: caller,
+		wake:   make(chan struct{}, 1),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[caller] == nil {
+		h.subscribers[caller] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[caller][sub] = struct{}{}
+
+	return sub
+}
+
+func (h *Hub) Notify(caller identity.Identity) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[caller] {
+		sub.signal()
+	}
+}
+
+// NotifyAll wakes every watcher, for when notifications may have been lost.
+func (h *Hub) NotifyAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, subs := range h.subscribers {
+		for sub := range subs {
+			sub.signal()
+		}
+	}
+}
+
+func (s *Subscription) Wake() <-chan struct{} {
+	return s.wake
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	subs := s.hub.subscribers[s.caller]
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(s.hub.subscribers, s.caller)
+	}
+}
+
+func (s *Subscription) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
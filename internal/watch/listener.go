@@ -0,0 +1,123 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/identity"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	notifyChannel = "task_events"
+	retryDelay    = 5 * time.Second
+)
+
+// Listener holds a dedicated connection that LISTENs for the notifications
+// sent by the record_task_event trigger and forwards them to the hub, so
+// watchers see changes written by any replica.
+type Listener struct {
+	db   *pgxpool.Pool
+	hub  *Hub
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewListener(db *pgxpool.Pool, hub *Hub) *Listener {
+	return &Listener{
+		db:   db,
+		hub:  hub,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+type notification struct {
+	TenantID string `json:"tenant_id"`
+	OwnerID  string `json:"owner_id"`
+}
+
+func (l *Listener) Start() {
+	defer close(l.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-l.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	slog.Info("Task event listener started", slog.String("channel", notifyChannel))
+
+	for {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Warn("Task event listener disconnected, retrying...",
+			slog.String("error", err.Error()),
+			slog.Duration("retry_in", retryDelay))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+func (l *Listener) Stop(ctx context.Context) error {
+	close(l.stop)
+
+	select {
+	case <-l.done:
+		slog.Info("Task event listener stopped")
+		return nil
+	case <-ctx.Done():
+		slog.Warn("Task event listener shutdown timeout")
+		return ctx.Err()
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	pooled, err := l.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The connection stays in LISTEN mode, so it never goes back to the pool.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+
+	// Anything committed while we were not listening was not announced.
+	l.hub.NotifyAll()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var payload notification
+		if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
+			slog.Warn("Malformed task event notification",
+				slog.String("payload", n.Payload),
+				slog.String("error", err.Error()))
+			l.hub.NotifyAll()
+			continue
+		}
+
+		l.hub.Notify(identity.Identity{TenantID: payload.TenantID, UserID: payload.OwnerID})
+	}
+}
@@ -0,0 +1,185 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/blob"
+	"github.com/Raisondetr3/checklist-db-service/internal/config"
+	"github.com/Raisondetr3/checklist-db-service/internal/repository"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+)
+
+// Purger periodically hard-deletes tasks that have been soft-deleted for
+// longer than the configured retention, and trims the task event log,
+// delivered outbox events and expired idempotency keys. It also deletes the
+// contents of removed attachments from the blob store.
+type Purger struct {
+	taskRepo        repository.TaskRepository
+	eventRepo       repository.TaskEventRepository
+	outboxRepo      repository.OutboxRepository
+	idempotencyRepo repository.IdempotencyRepository
+	attachmentRepo  repository.AttachmentRepository
+	blobStore       blob.BlobStore
+	config          config.PurgeConfig
+	stop            chan struct{}
+	done            chan struct{}
+}
+
+func NewPurger(cfg config.PurgeConfig, taskRepo repository.TaskRepository, eventRepo repository.TaskEventRepository, outboxRepo repository.OutboxRepository, idempotencyRepo repository.IdempotencyRepository, attachmentRepo repository.AttachmentRepository, blobStore blob.BlobStore) *Purger {
+	return &Purger{
+		taskRepo:        taskRepo,
+		eventRepo:       eventRepo,
+		outboxRepo:      outboxRepo,
+		idempotencyRepo: idempotencyRepo,
+		attachmentRepo:  attachmentRepo,
+		blobStore:       blobStore,
+		config:          cfg,
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+func (p *Purger) Start() {
+	defer close(p.done)
+
+	slog.Info("Purger started",
+		slog.Duration("retention", p.config.Retention),
+		slog.Duration("interval", p.config.Interval))
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge()
+
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) Stop(ctx context.Context) error {
+	close(p.stop)
+
+	select {
+	case <-p.done:
+		slog.Info("Purger stopped")
+		return nil
+	case <-ctx.Done():
+		slog.Warn("Purger shutdown timeout")
+		return ctx.Err()
+	}
+}
+
+func (p *Purger) purge() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	start := time.Now()
+	cutoff := start.Add(-p.config.Retention)
+
+	purged, err := p.taskRepo.PurgeDeletedBefore(ctx, cutoff, p.config.BatchSize)
+	if err != nil {
+		logger.LogError(ctx, err, "purge_deleted_tasks")
+	} else {
+		slog.Info("Purged deleted tasks",
+			slog.Int64("count", purged),
+			slog.Time("cutoff", cutoff),
+			slog.Duration("duration", time.Since(start)))
+	}
+
+	start = time.Now()
+	eventCutoff := start.Add(-p.config.EventRetention)
+
+	trimmed, err := p.eventRepo.DeleteBefore(ctx, eventCutoff, p.config.BatchSize)
+	if err != nil {
+		logger.LogError(ctx, err, "trim_task_events")
+	} else {
+		slog.Info("Trimmed task events",
+			slog.Int64("count", trimmed),
+			slog.Time("cutoff", eventCutoff),
+			slog.Duration("duration", time.Since(start)))
+	}
+
+	start = time.Now()
+	outboxCutoff := start.Add(-p.config.OutboxRetention)
+
+	trimmed, err = p.outboxRepo.DeleteDeliveredBefore(ctx, outboxCutoff, p.config.BatchSize)
+	if err != nil {
+		logger.LogError(ctx, err, "trim_outbox")
+	} else {
+		slog.Info("Trimmed delivered outbox events",
+			slog.Int64("count", trimmed),
+			slog.Time("cutoff", outboxCutoff),
+			slog.Duration("duration", time.Since(start)))
+	}
+
+	start = time.Now()
+
+	expired, err := p.idempotencyRepo.DeleteExpired(ctx, p.config.BatchSize)
+	if err != nil {
+		logger.LogError(ctx, err, "delete_expired_idempotency_keys")
+	} else {
+		slog.Info("Deleted expired idempotency keys",
+			slog.Int64("count", expired),
+			slog.Duration("duration", time.Since(start)))
+	}
+
+	start = time.Now()
+
+	deleted, err := p.deleteBlobs(ctx)
+	if err != nil {
+		logger.LogError(ctx, err, "delete_blobs")
+		return
+	}
+
+	slog.Info("Deleted blobs of removed attachments",
+		slog.Int64("count", deleted),
+		slog.Duration("duration", time.Since(start)))
+}
+
+// deleteBlobs deletes the contents of removed attachments from the blob
+// store in batches. A blob that cannot be deleted is left queued and tried
+// again on the next run.
+func (p *Purger) deleteBlobs(ctx context.Context) (int64, error) {
+	var total int64
+	for {
+		deletions, err := p.attachmentRepo.PendingBlobDeletions(ctx, p.config.BatchSize)
+		if err != nil || len(deletions) == 0 {
+			return total, err
+		}
+
+		done := make([]int64, 0, len(deletions))
+		for _, deletion := range deletions {
+			if err := p.blobStore.Delete(ctx, deletion.StorageKey); err != nil {
+				logger.LogError(ctx, err, "delete_blob")
+				continue
+			}
+			done = append(done, deletion.ID)
+		}
+		if len(done) == 0 {
+			return total, nil
+		}
+
+		if err := p.attachmentRepo.ForgetBlobDeletions(ctx, done); err != nil {
+			return total, err
+		}
+		total += int64(len(done))
+
+		if len(deletions) < p.config.BatchSize || len(done) < len(deletions) {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/config"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/internal/publisher"
+	"github.com/Raisondetr3/checklist-db-service/internal/repository"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+)
+
+// Relay publishes the events recorded in the outbox and marks them
+// delivered. Failed deliveries are retried with exponential backoff until
+// the configured number of attempts is used up.
+type Relay struct {
+	outboxRepo repository.OutboxRepository
+	publisher  publisher.Publisher
+	config     config.OutboxConfig
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+func NewRelay(cfg config.OutboxConfig, outboxRepo repository.OutboxRepository, pub publisher.Publisher) *Relay {
+	return &Relay{
+		outboxRepo: outboxRepo,
+		publisher:  pub,
+		config:     cfg,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+func (r *Relay) Start() {
+	defer close(r.done)
+
+	slog.Info("Outbox relay started",
+		slog.String("publisher", r.config.Publisher),
+		slog.Duration("interval", r.config.Interval))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-r.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.relay(ctx)
+
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) Stop(ctx context.Context) error {
+	close(r.stop)
+
+	select {
+	case <-r.done:
+		slog.Info("Outbox relay stopped")
+		return nil
+	case <-ctx.Done():
+		slog.Warn("Outbox relay shutdown timeout")
+		return ctx.Err()
+	}
+}
+
+// relay drains the outbox. Only the oldest pending event of each task is
+// claimable at a time, so it keeps going until a round claims nothing.
+func (r *Relay) relay(ctx context.Context) {
+	retry := model.RetryPolicy{
+		MaxAttempts: r.config.MaxAttempts,
+		BaseDelay:   r.config.RetryBaseDelay,
+		MaxDelay:    r.config.RetryMaxDelay,
+	}
+
+	for ctx.Err() == nil {
+		processed, err := r.outboxRepo.ProcessPending(ctx, r.config.BatchSize, r.config.Lease, retry, r.publish)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.LogError(ctx, err, "relay_outbox")
+			}
+			return
+		}
+		if processed == 0 {
+			return
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, event *model.OutboxEvent) error {
+	err := r.publisher.Publish(ctx, event)
+	if err != nil {
+		slog.Warn("Failed to publish outbox event",
+			slog.String("event_id", event.EventID.String()),
+			slog.String("event_type", event.Type),
+			slog.Int("attempt", event.Attempts),
+			slog.Int("max_attempts", r.config.MaxAttempts),
+			slog.String("error", err.Error()))
+	}
+	return err
+}
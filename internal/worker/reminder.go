@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Raisondetr3/checklist-db-service/internal/config"
+	"github.com/Raisondetr3/checklist-db-service/internal/model"
+	"github.com/Raisondetr3/checklist-db-service/internal/notifier"
+	"github.com/Raisondetr3/checklist-db-service/internal/repository"
+	"github.com/Raisondetr3/checklist-db-service/pkg/logger"
+)
+
+// Scheduler sends a reminder through the notifier once remind_at of an open
+// task passes. Failed notifications are retried with exponential backoff
+// until the configured number of attempts is used up.
+type Scheduler struct {
+	reminderRepo repository.ReminderRepository
+	notifier     notifier.Notifier
+	config       config.ReminderConfig
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+func NewScheduler(cfg config.ReminderConfig, reminderRepo repository.ReminderRepository, n notifier.Notifier) *Scheduler {
+	return &Scheduler{
+		reminderRepo: reminderRepo,
+		notifier:     n,
+		config:       cfg,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+func (s *Scheduler) Start() {
+	defer close(s.done)
+
+	slog.Info("Reminder scheduler started",
+		slog.String("notifier", s.config.Notifier),
+		slog.Duration("interval", s.config.Interval))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.remind(ctx)
+
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) Stop(ctx context.Context) error {
+	close(s.stop)
+
+	select {
+	case <-s.done:
+		slog.Info("Reminder scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		slog.Warn("Reminder scheduler shutdown timeout")
+		return ctx.Err()
+	}
+}
+
+// remind sends due reminders in batches until a round comes back short. A
+// failed reminder is not due again before its retry delay, so it cannot
+// keep the rounds full.
+func (s *Scheduler) remind(ctx context.Context) {
+	retry := model.RetryPolicy{
+		MaxAttempts: s.config.MaxAttempts,
+		BaseDelay:   s.config.RetryBaseDelay,
+		MaxDelay:    s.config.RetryMaxDelay,
+	}
+
+	for ctx.Err() == nil {
+		processed, err := s.reminderRepo.ProcessDue(ctx, s.config.BatchSize, s.config.Lease, retry, s.notify)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.LogError(ctx, err, "process_reminders")
+			}
+			return
+		}
+		if processed < s.config.BatchSize {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) notify(ctx context.Context, reminder *model.Reminder) error {
+	err := s.notifier.Notify(ctx, reminder)
+	if err != nil {
+		slog.Warn("Failed to send task reminder",
+			slog.String("task_id", reminder.Task.ID.String()),
+			slog.Time("remind_at", reminder.RemindAt),
+			slog.Int("attempt", reminder.Attempts),
+			slog.Int("max_attempts", s.config.MaxAttempts),
+			slog.String("error", err.Error()))
+	}
+	return err
+}
@@ -1,12 +1,216 @@
+CREATE TABLE IF NOT EXISTS task_lists (
+    id UUID PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    owner_id TEXT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
 CREATE TABLE IF NOT EXISTS tasks (
     id UUID PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    owner_id TEXT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    completed_from_items BOOLEAN NOT NULL DEFAULT FALSE,
+    version BIGINT NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    due_at TIMESTAMP WITH TIME ZONE,
+    remind_at TIMESTAMP WITH TIME ZONE,
+    recurrence TEXT NOT NULL DEFAULT '',
+    series_id UUID,
+    recurrence_start TIMESTAMP WITH TIME ZONE,
+    tags TEXT[] NOT NULL DEFAULT '{}' CHECK (cardinality(tags) <= 50),
+    priority SMALLINT NOT NULL DEFAULT 0 CHECK (priority BETWEEN 0 AND 4),
+    position DOUBLE PRECISION NOT NULL,
+    -- Deleting a list is refused while it holds live tasks; tasks already in
+    -- the trash fall back to no list.
+    list_id UUID REFERENCES task_lists (id) ON DELETE SET NULL,
+    -- Purging a task promotes its subtasks to top-level tasks.
+    parent_id UUID REFERENCES tasks (id) ON DELETE SET NULL CHECK (parent_id <> id),
+    -- Values of the fields defined for the task's list, by key. The service
+    -- validates them against task_list_fields.
+    custom_fields JSONB NOT NULL DEFAULT '{}' CHECK (jsonb_typeof(custom_fields) = 'object'),
+    search_vector TSVECTOR
+);
+
+-- Custom fields the tasks of a list may carry. Deleting a field removes its
+-- values from the list's tasks.
+CREATE TABLE IF NOT EXISTS task_list_fields (
+    id UUID PRIMARY KEY,
+    list_id UUID NOT NULL REFERENCES task_lists (id) ON DELETE CASCADE,
+    key VARCHAR(64) NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('string', 'number', 'date', 'enum')),
+    options TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (list_id, key)
+);
+
+-- Tasks created over and over. Titles and descriptions may hold {{name}}
+-- placeholders that are filled in when a task is created from the template.
+-- items holds the titles of the task's checklist items and subtasks the
+-- tasks nested under it, each as {"title", "description", "items"}.
+CREATE TABLE IF NOT EXISTS task_templates (
+    id UUID PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    owner_id TEXT NOT NULL,
     title VARCHAR(255) NOT NULL,
     description TEXT,
+    priority SMALLINT NOT NULL DEFAULT 0 CHECK (priority BETWEEN 0 AND 4),
+    tags TEXT[] NOT NULL DEFAULT '{}' CHECK (cardinality(tags) <= 50),
+    -- Templates outlive the lists they file their tasks in.
+    list_id UUID REFERENCES task_lists (id) ON DELETE SET NULL,
+    custom_fields JSONB NOT NULL DEFAULT '{}' CHECK (jsonb_typeof(custom_fields) = 'object'),
+    items TEXT[] NOT NULL DEFAULT '{}',
+    subtasks JSONB NOT NULL DEFAULT '[]' CHECK (jsonb_typeof(subtasks) = 'array'),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS task_items (
+    id UUID PRIMARY KEY,
+    task_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
     completed BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL,
     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
 
+-- task_id cannot be completed while blocker_id is open. Both belong to the
+-- same owner.
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    task_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    blocker_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, blocker_id),
+    CHECK (task_id <> blocker_id)
+);
+
+-- Users other than the owner a task is shared with. Assignees may edit the
+-- task without a row here.
+CREATE TABLE IF NOT EXISTS task_acl (
+    task_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    permission TEXT NOT NULL CHECK (permission IN ('read', 'edit')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS task_assignees (
+    task_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    assigned_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, user_id)
+);
+
+-- Comments of a task in the trash are out of reach until it is restored,
+-- and are removed along with it when it is purged.
+CREATE TABLE IF NOT EXISTS task_comments (
+    id UUID PRIMARY KEY,
+    task_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    author_id TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    edited_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Attachments of a task in the trash are out of reach until it is
+-- restored but still count towards the owner's quota.
+CREATE TABLE IF NOT EXISTS task_attachments (
+    id UUID PRIMARY KEY,
+    task_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    uploader_id TEXT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL CHECK (size >= 0),
+    content_type TEXT NOT NULL,
+    checksum TEXT NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Contents of removed attachments, left for the purger to delete from the
+-- blob store.
+CREATE TABLE IF NOT EXISTS blob_deletions (
+    id BIGSERIAL PRIMARY KEY,
+    storage_key TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- One row per task whose reminder has been claimed. remind_at records which
+-- reminder it is, so moving remind_at on the task arms a new one. A claim
+-- is leased until locked_until while it is being sent, retried from
+-- next_attempt_at after a failed notification, and done once sent or given
+-- up on.
+CREATE TABLE IF NOT EXISTS task_reminders (
+    task_id UUID PRIMARY KEY REFERENCES tasks (id) ON DELETE CASCADE,
+    remind_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    failed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS task_events (
+    seq BIGSERIAL PRIMARY KEY,
+    txid XID8 NOT NULL DEFAULT pg_current_xact_id(),
+    task_id UUID NOT NULL,
+    tenant_id TEXT NOT NULL,
+    owner_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    task JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    aggregate_id UUID NOT NULL,
+    tenant_id TEXT NOT NULL,
+    owner_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    failed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS task_history (
+    id BIGSERIAL PRIMARY KEY,
+    task_id UUID NOT NULL,
+    tenant_id TEXT NOT NULL,
+    owner_id TEXT NOT NULL,
+    actor_id TEXT NOT NULL,
+    request_id TEXT,
+    action TEXT NOT NULL,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    tenant_id TEXT NOT NULL,
+    owner_id TEXT NOT NULL,
+    key TEXT NOT NULL,
+    operation TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    response BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (tenant_id, owner_id, key)
+);
+
 CREATE OR REPLACE FUNCTION update_updated_at_column()
 RETURNS TRIGGER AS $$
 BEGIN
@@ -15,5 +219,255 @@ BEGIN
 END;
 $$ language 'plpgsql';
 
+-- Reordering is not a change to the task itself, so a write that only moves
+-- the task keeps its version and updated_at.
+CREATE OR REPLACE FUNCTION update_tasks_updated_at_and_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.position IS DISTINCT FROM OLD.position
+        AND to_jsonb(NEW) - 'position' = to_jsonb(OLD) - 'position' THEN
+        RETURN NEW;
+    END IF;
+    NEW.updated_at = NOW();
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+-- New tasks go to the end of the owner's manual order.
+CREATE OR REPLACE FUNCTION assign_task_position()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.position IS NULL THEN
+        SELECT COALESCE(MAX(position), 0) + 1 INTO NEW.position
+        FROM tasks WHERE tenant_id = NEW.tenant_id AND owner_id = NEW.owner_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER assign_task_position BEFORE INSERT
+    ON tasks FOR EACH ROW EXECUTE FUNCTION assign_task_position();
+
 CREATE TRIGGER update_tasks_updated_at BEFORE UPDATE
-    ON tasks FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
\ No newline at end of file
+    ON tasks FOR EACH ROW EXECUTE FUNCTION update_tasks_updated_at_and_version();
+
+CREATE TRIGGER update_task_items_updated_at BEFORE UPDATE
+    ON task_items FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_task_lists_updated_at BEFORE UPDATE
+    ON task_lists FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_task_templates_updated_at BEFORE UPDATE
+    ON task_templates FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- The foreign key only proves the list exists; a task may only be filed in
+-- a list of its own owner.
+CREATE OR REPLACE FUNCTION check_task_list_owner()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.list_id IS NOT NULL AND NOT EXISTS (
+        SELECT 1 FROM task_lists
+        WHERE id = NEW.list_id AND tenant_id = NEW.tenant_id AND owner_id = NEW.owner_id
+    ) THEN
+        RAISE EXCEPTION 'task list % not found', NEW.list_id
+            USING ERRCODE = 'foreign_key_violation', CONSTRAINT = 'tasks_list_id_fkey';
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER check_task_list_owner BEFORE INSERT OR UPDATE OF list_id
+    ON tasks FOR EACH ROW EXECUTE FUNCTION check_task_list_owner();
+
+CREATE TRIGGER check_task_template_list_owner BEFORE INSERT OR UPDATE OF list_id
+    ON task_templates FOR EACH ROW EXECUTE FUNCTION check_task_list_owner();
+
+-- A task may only be nested under a live task of its own owner, and never
+-- under itself or one of its subtasks. Changes of an owner's hierarchy are
+-- serialized so that two concurrent moves cannot close a cycle between
+-- them, and the parent is locked so it cannot be deleted meanwhile.
+CREATE OR REPLACE FUNCTION check_task_parent()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.parent_id IS NULL OR (TG_OP = 'UPDATE' AND NEW.parent_id IS NOT DISTINCT FROM OLD.parent_id) THEN
+        RETURN NEW;
+    END IF;
+
+    PERFORM pg_advisory_xact_lock(hashtext('task_parents:' || NEW.tenant_id || ':' || NEW.owner_id));
+
+    PERFORM 1 FROM tasks
+    WHERE id = NEW.parent_id AND tenant_id = NEW.tenant_id AND owner_id = NEW.owner_id AND deleted_at IS NULL
+    FOR SHARE;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'parent task % not found', NEW.parent_id
+            USING ERRCODE = 'foreign_key_violation', CONSTRAINT = 'tasks_parent_id_fkey';
+    END IF;
+
+    IF TG_OP = 'UPDATE' AND EXISTS (
+        WITH RECURSIVE ancestors (id) AS (
+            SELECT NEW.parent_id
+            UNION
+            SELECT t.parent_id FROM tasks t JOIN ancestors a ON t.id = a.id
+            WHERE t.parent_id IS NOT NULL
+        )
+        SELECT 1 FROM ancestors WHERE id = NEW.id
+    ) THEN
+        RAISE EXCEPTION 'task % cannot be nested under its own subtask', NEW.id
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'tasks_parent_id_check';
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER check_task_parent BEFORE INSERT OR UPDATE OF parent_id
+    ON tasks FOR EACH ROW EXECUTE FUNCTION check_task_parent();
+
+CREATE OR REPLACE FUNCTION record_task_event()
+RETURNS TRIGGER AS $$
+DECLARE
+    event_type TEXT;
+    task_row tasks;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        event_type := 'created';
+        task_row := NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        -- Purging an already soft-deleted task is not a visible change.
+        IF OLD.deleted_at IS NOT NULL THEN
+            RETURN OLD;
+        END IF;
+        event_type := 'deleted';
+        task_row := OLD;
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        event_type := 'deleted';
+        task_row := NEW;
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        event_type := 'restored';
+        task_row := NEW;
+    ELSE
+        event_type := 'updated';
+        task_row := NEW;
+    END IF;
+
+    INSERT INTO task_events (task_id, tenant_id, owner_id, event_type, task)
+    VALUES (task_row.id, task_row.tenant_id, task_row.owner_id, event_type,
+            to_jsonb(task_row) - 'search_vector');
+
+    PERFORM pg_notify('task_events', json_build_object(
+        'tenant_id', task_row.tenant_id,
+        'owner_id', task_row.owner_id
+    )::text);
+
+    RETURN task_row;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER record_task_event AFTER INSERT OR UPDATE OR DELETE
+    ON tasks FOR EACH ROW EXECUTE FUNCTION record_task_event();
+
+-- The actor and request id are set per transaction by the repository with
+-- set_config('app.actor_id', ..., true); background jobs leave them unset.
+CREATE OR REPLACE FUNCTION record_task_history()
+RETURNS TRIGGER AS $$
+DECLARE
+    action TEXT;
+    task_row tasks;
+    before_row JSONB;
+    after_row JSONB;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        action := 'created';
+        task_row := NEW;
+        after_row := to_jsonb(NEW) - 'search_vector';
+    ELSIF TG_OP = 'DELETE' THEN
+        action := 'purged';
+        task_row := OLD;
+        before_row := to_jsonb(OLD) - 'search_vector';
+    ELSE
+        -- A write that only moves the task is not a change, as for its
+        -- version.
+        IF NEW.position IS DISTINCT FROM OLD.position
+            AND to_jsonb(NEW) - 'position' = to_jsonb(OLD) - 'position' THEN
+            RETURN NULL;
+        END IF;
+        IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+            action := 'deleted';
+        ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+            action := 'restored';
+        ELSE
+            action := 'updated';
+        END IF;
+        task_row := NEW;
+        before_row := to_jsonb(OLD) - 'search_vector';
+        after_row := to_jsonb(NEW) - 'search_vector';
+    END IF;
+
+    INSERT INTO task_history (task_id, tenant_id, owner_id, actor_id, request_id, action, before, after)
+    VALUES (task_row.id, task_row.tenant_id, task_row.owner_id,
+            COALESCE(NULLIF(current_setting('app.actor_id', true), ''), 'system'),
+            NULLIF(current_setting('app.request_id', true), ''),
+            action, before_row, after_row);
+
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER record_task_history AFTER INSERT OR UPDATE OR DELETE
+    ON tasks FOR EACH ROW EXECUTE FUNCTION record_task_history();
+
+-- Also fires when purging a task removes its attachments.
+CREATE OR REPLACE FUNCTION queue_blob_deletion()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO blob_deletions (storage_key) VALUES (OLD.storage_key);
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER queue_blob_deletion AFTER DELETE
+    ON task_attachments FOR EACH ROW EXECUTE FUNCTION queue_blob_deletion();
+
+CREATE OR REPLACE FUNCTION update_tasks_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector =
+        setweight(to_tsvector('simple', coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(NEW.description, '')), 'B');
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER update_tasks_search_vector BEFORE INSERT OR UPDATE OF title, description
+    ON tasks FOR EACH ROW EXECUTE FUNCTION update_tasks_search_vector();
+
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_created_at_id ON tasks (tenant_id, owner_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_updated_at_id ON tasks (tenant_id, owner_id, updated_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_position_id ON tasks (tenant_id, owner_id, position, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_priority_id ON tasks (tenant_id, owner_id, priority, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_list_id ON tasks (list_id) WHERE list_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks (parent_id) WHERE parent_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_task_lists_owner_created_at_id ON task_lists (tenant_id, owner_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_task_templates_owner_created_at_id ON task_templates (tenant_id, owner_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_search_vector ON tasks USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_tasks_tags ON tasks USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_tasks_custom_fields ON tasks USING GIN (custom_fields);
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocker_id ON task_dependencies (blocker_id);
+CREATE INDEX IF NOT EXISTS idx_task_acl_user_id ON task_acl (user_id);
+CREATE INDEX IF NOT EXISTS idx_task_assignees_user_id ON task_assignees (user_id, task_id);
+CREATE INDEX IF NOT EXISTS idx_task_comments_task_created_at_id ON task_comments (task_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_task_attachments_task_created_at_id ON task_attachments (task_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_task_items_task_position ON task_items (task_id, position);
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_due_at ON tasks (tenant_id, owner_id, due_at) WHERE due_at IS NOT NULL AND NOT completed AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_remind_at ON tasks (remind_at) WHERE remind_at IS NOT NULL AND NOT completed AND deleted_at IS NULL;
+-- Keeps completing a recurring task twice from spawning the same occurrence
+-- twice.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_series_due_at ON tasks (series_id, due_at) WHERE series_id IS NOT NULL AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_deleted_at ON tasks (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_task_events_owner_position ON task_events (tenant_id, owner_id, txid, seq);
+CREATE INDEX IF NOT EXISTS idx_task_events_created_at ON task_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (id) WHERE delivered_at IS NULL AND failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate_pending ON outbox (aggregate_id, id) WHERE delivered_at IS NULL AND failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_delivered_at ON outbox (delivered_at) WHERE delivered_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_task_history_task_id ON task_history (tenant_id, owner_id, task_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);